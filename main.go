@@ -1,9 +1,14 @@
 package main
 
 import (
+	"os"
+
 	"github.com/spinkube/spin-plugin-kube/pkg/cmd"
 )
 
 func main() {
-	cmd.Execute()
+	// errors are printed by the commands, and the diff commands return an error to exit with code 1 on changes
+	if err := cmd.Execute(); err != nil {
+		os.Exit(1)
+	}
 }
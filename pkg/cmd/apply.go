@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// manifestExtensions are the extensions of the files read from a directory.
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+var (
+	applyFilenames []string
+	applyRecursive bool
+	applyDryRun    string
+	applyOwnership fieldOwnershipOptions
+)
+
+// fieldOwnershipOptions control the field manager that owns the applied fields, and whether the fields other field
+// managers own are taken over.
+type fieldOwnershipOptions struct {
+	fieldManager   string
+	forceConflicts bool
+}
+
+func (o *fieldOwnershipOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.fieldManager, "field-manager", kube.FieldManager, "Name of the field manager that owns the applied fields")
+	flags.BoolVar(&o.forceConflicts, "force-conflicts", false, "Take the ownership of fields that other field managers own, such as Argo CD, Flux or kubectl, instead of failing")
+}
+
+// applyOptions returns the options to apply objects with, where namespaced objects without a namespace are applied to
+// namespace.
+func (o fieldOwnershipOptions) applyOptions(namespace string) kube.ApplyOptions {
+	return kube.ApplyOptions{
+		Namespace:      namespace,
+		FieldManager:   o.fieldManager,
+		ForceConflicts: o.forceConflicts,
+	}
+}
+
+var applyCmd = &cobra.Command{
+	Use:    "apply",
+	Short:  "Apply manifests to Kubernetes with server-side apply",
+	Hidden: isExperimentalFlagNotSet,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		opts := applyOwnership.applyOptions(namespace)
+		switch applyDryRun {
+		case "none":
+		case "server":
+			opts.DryRun = true
+		default:
+			return fmt.Errorf("invalid value '%s' for --dry-run; expected 'none' or 'server'", applyDryRun)
+		}
+
+		objects, err := readManifests(applyFilenames, applyRecursive, os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		if len(objects) == 0 {
+			return fmt.Errorf("no objects passed to apply")
+		}
+
+		return applyObjects(context.TODO(), os.Stdout, opts, objects...)
+	},
+}
+
+// readManifests reads the objects from the files, the manifest files in the directories and, for "-", from stdin. The
+// subdirectories of a directory are only read when recursive is set.
+func readManifests(filenames []string, recursive bool, stdin io.Reader) ([]runtime.Object, error) {
+	var objects []runtime.Object
+	for _, filename := range filenames {
+		if filename == "-" {
+			decoded, err := decodeManifests(stdin, "stdin")
+			if err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, decoded...)
+			continue
+		}
+
+		paths, err := manifestPaths(filename, recursive)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range paths {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+
+			decoded, err := decodeManifests(file, path)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, decoded...)
+		}
+	}
+
+	return objects, nil
+}
+
+// manifestPaths returns the path itself for a file, and the manifest files in lexical order for a directory.
+func manifestPaths(path string, recursive bool) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(path, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		for _, ext := range manifestExtensions {
+			if strings.EqualFold(filepath.Ext(p), ext) {
+				paths = append(paths, p)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	return paths, err
+}
+
+// decodeManifests decodes the YAML or JSON documents into unstructured objects. Empty documents are skipped and lists
+// are expanded into their items.
+func decodeManifests(r io.Reader, source string) ([]runtime.Object, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(r, 4096)
+
+	var objects []runtime.Object
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+
+		if len(u.Object) == 0 {
+			continue
+		}
+
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			return nil, fmt.Errorf("failed to parse %s: every object must set apiVersion and kind", source)
+		}
+
+		if !u.IsList() {
+			objects = append(objects, u)
+			continue
+		}
+
+		list, err := u.ToList()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+}
+
+// applyObjects applies the objects with server-side apply and prints for every object whether it was created,
+// configured or unchanged. SpinApps are applied last, so that the runtime config Secrets and the other objects they
+// depend on exist by the time the operator sees them.
+func applyObjects(ctx context.Context, w io.Writer, opts kube.ApplyOptions, objects ...runtime.Object) error {
+	ordered := make([]runtime.Object, 0, len(objects))
+	var spinapps []runtime.Object
+	for _, obj := range objects {
+		if obj.GetObjectKind().GroupVersionKind().Kind == "SpinApp" {
+			spinapps = append(spinapps, obj)
+			continue
+		}
+
+		ordered = append(ordered, obj)
+	}
+
+	suffix := ""
+	if opts.DryRun {
+		suffix = " (server dry run)"
+	}
+
+	for _, obj := range append(ordered, spinapps...) {
+		u, err := toPrintable(obj)
+		if err != nil {
+			return err
+		}
+
+		result, err := kubeImpl.ApplyObject(ctx, u, opts)
+		if err != nil {
+			var conflictErr *kube.ConflictError
+			if errors.As(err, &conflictErr) {
+				return fmt.Errorf("failed to apply %s: %w\nrerun with --force-conflicts to take the ownership of these fields", objectReference(obj), err)
+			}
+
+			return fmt.Errorf("failed to apply %s: %w", objectReference(obj), err)
+		}
+
+		fmt.Fprintf(w, "%s %s%s\n", objectReference(obj), result, suffix)
+	}
+
+	return nil
+}
+
+// objectReference returns the reference kubectl prints for an object, e.g. spinapp.core.spinkube.dev/example-app.
+func objectReference(obj runtime.Object) string {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+
+	resource := strings.ToLower(gvk.Kind)
+	if gvk.Group != "" {
+		resource += "." + gvk.Group
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return resource
+	}
+
+	return resource + "/" + accessor.GetName()
+}
+
+func init() {
+	applyCmd.Flags().StringSliceVarP(&applyFilenames, "filename", "f", nil, "File, directory or - for stdin with the manifests to apply. This can be specified multiple times")
+	applyCmd.Flags().BoolVarP(&applyRecursive, "recursive", "R", false, "Read the manifests in the subdirectories of the directories passed with --filename")
+	applyCmd.Flags().StringVar(&applyDryRun, "dry-run", "none", "Must be 'none' or 'server'. With 'server', the objects are sent to the API server without being persisted")
+	applyOwnership.addFlags(applyCmd.Flags())
+
+	if err := applyCmd.MarkFlagRequired("filename"); err != nil {
+		log.Fatal(err)
+	}
+
+	configFlags.AddFlags(applyCmd.Flags())
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+const testSpinApp = `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinApp
+metadata:
+  name: example-app
+spec:
+  executor: containerd-shim-spin
+  image: ghcr.io/foo/example-app:v0.1.0
+  replicas: 2
+`
+
+func objectReferences(objects []runtime.Object) []string {
+	references := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		references = append(references, objectReference(obj))
+	}
+
+	return references
+}
+
+func TestReadManifests(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "nested"), 0700))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(testSpinApp+`---
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: example-app-runtime-config
+`), 0600))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest\n"), 0600))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nested", "autoscaler.json"), []byte(`{"apiVersion": "autoscaling/v2", "kind": "HorizontalPodAutoscaler", "metadata": {"name": "example-app-autoscaler"}}`), 0600))
+
+	objects, err := readManifests([]string{dir}, false, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{"spinapp.core.spinkube.dev/example-app", "secret/example-app-runtime-config"}, objectReferences(objects))
+
+	objects, err = readManifests([]string{dir}, true, nil)
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"spinapp.core.spinkube.dev/example-app",
+		"secret/example-app-runtime-config",
+		"horizontalpodautoscaler.autoscaling/example-app-autoscaler",
+	}, objectReferences(objects))
+
+	stdin := strings.NewReader(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+- apiVersion: v1
+  kind: Namespace
+  metadata:
+    name: shop
+`)
+	objects, err = readManifests([]string{"-", filepath.Join(dir, "nested", "autoscaler.json")}, false, stdin)
+	require.Nil(t, err)
+	require.Equal(t, []string{"configmap/settings", "namespace/shop", "horizontalpodautoscaler.autoscaling/example-app-autoscaler"}, objectReferences(objects))
+}
+
+func TestReadManifestsInvalid(t *testing.T) {
+	_, err := readManifests([]string{"-"}, false, strings.NewReader("metadata:\n  name: example-app\n"))
+	require.EqualError(t, err, "failed to parse stdin: every object must set apiVersion and kind")
+
+	_, err = readManifests([]string{"-"}, false, strings.NewReader("kind: [SpinApp\n"))
+	require.ErrorContains(t, err, "failed to parse stdin: ")
+
+	_, err = readManifests([]string{"testdata/missing.yaml"}, false, nil)
+	require.ErrorContains(t, err, "no such file or directory")
+}
+
+func TestApplyManifests(t *testing.T) {
+	objects, err := readManifests([]string{"-"}, false, strings.NewReader(testSpinApp+`---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: other
+`))
+	require.Nil(t, err)
+
+	applied := map[string]string{}
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(newScheme())).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, opts ...client.PatchOption) error {
+			options := &client.PatchOptions{}
+			options.ApplyOptions(opts)
+			require.Equal(t, []string{metav1.DryRunAll}, options.DryRun)
+
+			applied[obj.GetName()] = obj.GetNamespace()
+			return nil
+		},
+	}).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	var output strings.Builder
+	require.Nil(t, applyObjects(context.Background(), &output, kube.ApplyOptions{Namespace: "default", DryRun: true}, objects...))
+
+	require.Equal(t, map[string]string{"example-app": "default", "shop": "", "settings": "other"}, applied)
+	require.Equal(t, `namespace/shop created (server dry run)
+configmap/settings created (server dry run)
+spinapp.core.spinkube.dev/example-app created (server dry run)
+`, output.String())
+}
+
+func TestApplyManifestsConflict(t *testing.T) {
+	objects, err := readManifests([]string{"-"}, false, strings.NewReader(testSpinApp))
+	require.Nil(t, err)
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(newScheme())).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, _ client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			return &apierrors.StatusError{ErrStatus: metav1.Status{
+				Status: metav1.StatusFailure,
+				Code:   http.StatusConflict,
+				Reason: metav1.StatusReasonConflict,
+				Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{
+					{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "argocd-controller" using core.spinkube.dev/v1alpha1`, Field: ".spec.replicas"},
+				}},
+			}}
+		},
+	}).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	err = applyObjects(context.Background(), io.Discard, kube.ApplyOptions{Namespace: "default"}, objects...)
+	require.EqualError(t, err, `failed to apply spinapp.core.spinkube.dev/example-app: 1 field(s) are owned by other field managers:
+  .spec.replicas (owned by argocd-controller)
+rerun with --force-conflicts to take the ownership of these fields`)
+}
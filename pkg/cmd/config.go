@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the project config",
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Display the project config resolved for the selected profile",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		config, err := loadProjectConfig()
+		if err != nil {
+			return err
+		}
+
+		if config == nil {
+			return fmt.Errorf("no %s file or [tool.spinkube] table in %s found in the current directory", projectConfigFileName, spinManifestFileName)
+		}
+
+		return printProjectConfig(os.Stdout, config, profile)
+	},
+}
+
+// printProjectConfig writes the settings of the profile, merged with the top-level settings, as YAML.
+func printProjectConfig(w io.Writer, config *projectConfig, profile string) error {
+	settings, err := config.resolve(profile)
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "# source: %s\n", config.path)
+	if profile != "" {
+		fmt.Fprintf(w, "# profile: %s\n", profile)
+	}
+
+	_, err = w.Write(content)
+	return err
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -15,7 +15,7 @@ import (
 	"k8s.io/kubectl/pkg/polymorphichelpers"
 )
 
-const spinAppPort = "80"
+const spinAppPort = 80
 
 var connectCmd = &cobra.Command{
 	Use:    "connect <name>",
@@ -106,7 +106,7 @@ var connectCmd = &cobra.Command{
 		}()
 
 		ccmd := portforward.NewCmdPortForward(factory, streams)
-		ccmd.Run(ccmd, []string{reference, fmt.Sprintf("%s:%s", localPort, spinAppPort)})
+		ccmd.Run(ccmd, []string{reference, fmt.Sprintf("%s:%d", localPort, spinAppPort)})
 
 		return nil
 	},
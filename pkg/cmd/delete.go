@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spinkube/spin-plugin-kube/pkg/prompt"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,7 +31,7 @@ var deleteCmd = &cobra.Command{
 		}
 
 		if !yes {
-			yes, err = yesOrNo("This action is irreversible. Are you sure? (y/N): ")
+			yes, err = prompt.New(os.Stdin, os.Stdout).Confirm("This action is irreversible. Are you sure?", false)
 			if err != nil {
 				return err
 			}
@@ -1,75 +1,127 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
-	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/cli-runtime/pkg/printers"
+	"github.com/spf13/pflag"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"golang.org/x/term"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
-	artifact string
-	replicas int32
-	dryRun   bool
+	dryRun          bool
+	deployOpts      = ScaffoldOptions{}
+	deployWait      bool
+	deployDiff      bool
+	deployOwnership fieldOwnershipOptions
+	deployTimeout   time.Duration
 )
 
 var deployCmd = &cobra.Command{
 	Use:    "deploy",
 	Short:  "Deploy application to Kubernetes",
+	Args:   cobra.NoArgs,
 	Hidden: isExperimentalFlagNotSet,
-	RunE: func(_ *cobra.Command, _ []string) error {
-		name, err := getNameFromImageReference(artifact)
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		opts := deployOpts
+		opts.namespace = namespace
+
+		config, err := newAppConfig(opts)
 		if err != nil {
 			return err
 		}
 
-		spinapp := spinv1alpha1.SpinApp{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      name,
-				Namespace: namespace,
-			},
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "core.spinkube.dev/v1alpha1",
-				Kind:       "SpinApp",
-			},
-			Spec: spinv1alpha1.SpinAppSpec{
-				Replicas: replicas,
-				Image:    artifact,
-				Executor: "containerd-shim-spin",
-			},
+		objects, err := buildObjects(config)
+		if err != nil {
+			return err
 		}
 
 		if dryRun {
-			y := printers.YAMLPrinter{}
-			if err := y.PrintObj(&spinapp, os.Stdout); err != nil {
+			return printObjects(os.Stdout, objects...)
+		}
+
+		if deployDiff {
+			return runDiff(context.TODO(), cmd, os.Stdout, deployOwnership.applyOptions(namespace), objects...)
+		}
+
+		warnIfExecutorMissing(context.TODO(), namespace, config.Executor)
+
+		key := client.ObjectKey{Namespace: namespace, Name: config.Name}
+		var baseline kube.RolloutBaseline
+		if deployWait {
+			// the rollout of the previous spec must not be mistaken for the rollout of the applied one
+			if baseline, err = kubeImpl.GetRolloutBaseline(context.TODO(), key); err != nil {
 				return err
 			}
-			return nil
 		}
 
-		if err := kubeImpl.ApplySpinApp(context.TODO(), &spinapp); err != nil {
+		if err := applyObjects(context.TODO(), os.Stdout, deployOwnership.applyOptions(namespace), objects...); err != nil {
 			return err
 		}
 
-		fmt.Printf("spinapp.spin.fermyon.com/%s configured\n", name)
-		return nil
+		if !deployWait {
+			return nil
+		}
+
+		return waitForApp(context.TODO(), os.Stderr, key, baseline, deployTimeout, term.IsTerminal(int(os.Stderr.Fd())))
 	},
 }
 
+// waitForApp waits for the rollout of the application. On a terminal, the progress is shown on a single line that is
+// updated after every check.
+func waitForApp(ctx context.Context, w io.Writer, name client.ObjectKey, baseline kube.RolloutBaseline, timeout time.Duration, tty bool) error {
+	var progress func(kube.RolloutStatus)
+	if tty {
+		progress = func(status kube.RolloutStatus) {
+			message := cmp.Or(status.Message, "rollout complete")
+			fmt.Fprintf(w, "\r\033[KWaiting for %s: %s", name.Name, message)
+		}
+	}
+
+	err := kubeImpl.WaitForSpinAppReady(ctx, name, baseline, timeout, progress)
+	if tty {
+		fmt.Fprintln(w)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s is ready\n", name.Name)
+	return nil
+}
+
 func init() {
-	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the kubernetes manifest without deploying")
-	deployCmd.Flags().Int32VarP(&replicas, "replicas", "r", 2, "Number of replicas for the application")
-	deployCmd.Flags().StringVarP(&artifact, "from", "f", "", "Reference in the registry of the application")
+	deployCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the kubernetes manifests without deploying")
+	deployCmd.Flags().BoolVar(&deployWait, "wait", false, "Wait until the application is rolled out and all its replicas are ready")
+	deployCmd.Flags().DurationVar(&deployTimeout, "timeout", 5*time.Minute, "How long to wait for the application with --wait before failing")
+	deployCmd.Flags().BoolVar(&deployDiff, "diff", false, "Only print the changes deploying would make to the cluster, and exit with code 1 when there are changes")
+	deployCmd.MarkFlagsMutuallyExclusive("dry-run", "diff", "wait")
+	deployOwnership.addFlags(deployCmd.Flags())
+	configFlags.AddFlags(deployCmd.Flags())
+
+	// the kubeconfig flags keep their shorthands where they clash with the shorthands of the scaffold flags, e.g. -s
+	scaffoldFlags := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+	deployOpts.addFlags(scaffoldFlags)
+	scaffoldFlags.VisitAll(func(f *pflag.Flag) {
+		if deployCmd.Flags().ShorthandLookup(f.Shorthand) != nil {
+			f.Shorthand = ""
+		}
+
+		deployCmd.Flags().AddFlag(f)
+	})
 
 	if err := deployCmd.MarkFlagRequired("from"); err != nil {
 		log.Fatal(err)
 	}
 
-	configFlags.AddFlags(deployCmd.Flags())
 	rootCmd.AddCommand(deployCmd)
 }
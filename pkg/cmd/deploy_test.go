@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestApplyObjects(t *testing.T) {
+	opts := ScaffoldOptions{
+		from:                              "ghcr.io/foo/example-app:v0.1.0",
+		namespace:                         "shop",
+		executor:                          defaultExecutorName,
+		replicas:                          2,
+		maxReplicas:                       3,
+		autoscaler:                        "hpa",
+		cpuLimit:                          "100m",
+		memoryLimit:                       "128Mi",
+		targetCPUUtilizationPercentage:    60,
+		targetMemoryUtilizationPercentage: 60,
+		configfile:                        "testdata/runtime-config.toml",
+	}
+
+	config, err := newAppConfig(opts)
+	require.Nil(t, err)
+
+	objects, err := buildObjects(config)
+	require.Nil(t, err)
+
+	var applied []string
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(newScheme())).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			require.Equal(t, client.Apply, patch)
+
+			options := &client.PatchOptions{}
+			options.ApplyOptions(opts)
+			require.Equal(t, kube.FieldManager, options.FieldManager)
+			require.Equal(t, "shop", obj.GetNamespace())
+
+			applied = append(applied, obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName())
+			return nil
+		},
+	}).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	var output strings.Builder
+	require.Nil(t, applyObjects(context.Background(), &output, kube.ApplyOptions{}, objects...))
+
+	require.Equal(t, []string{
+		"Secret/example-app-runtime-config",
+		"HorizontalPodAutoscaler/example-app-autoscaler",
+		"SpinApp/example-app",
+	}, applied)
+	require.Equal(t, `secret/example-app-runtime-config created
+horizontalpodautoscaler.autoscaling/example-app-autoscaler created
+spinapp.core.spinkube.dev/example-app created
+`, output.String())
+}
+
+func TestWaitForApp(t *testing.T) {
+	app := &spinv1alpha1.SpinApp{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+		Spec:       spinv1alpha1.SpinAppSpec{Replicas: 1},
+		Status:     spinv1alpha1.SpinAppStatus{ReadyReplicas: 1},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr(int32(1))},
+		Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(app, deployment).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	key := client.ObjectKey{Namespace: "default", Name: "example-app"}
+
+	var output strings.Builder
+	require.Nil(t, waitForApp(context.Background(), &output, key, kube.RolloutBaseline{}, time.Second, true))
+	require.Equal(t, "\r\033[KWaiting for example-app: rollout complete\nexample-app is ready\n", output.String())
+
+	output.Reset()
+	require.Nil(t, waitForApp(context.Background(), &output, key, kube.RolloutBaseline{}, time.Second, false))
+	require.Equal(t, "example-app is ready\n", output.String())
+}
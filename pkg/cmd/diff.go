@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	diffFilenames []string
+	diffRecursive bool
+	diffOwnership fieldOwnershipOptions
+)
+
+var diffCmd = &cobra.Command{
+	Use:    "diff",
+	Short:  "Show the changes applying manifests would make to the cluster",
+	Long:   "Show the changes applying manifests would make to the cluster. The exit code is 1 when there are changes, so that it can be used to detect drift.",
+	Hidden: isExperimentalFlagNotSet,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		objects, err := readManifests(diffFilenames, diffRecursive, os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		return runDiff(context.TODO(), cmd, os.Stdout, diffOwnership.applyOptions(namespace), objects...)
+	},
+}
+
+// errChangesFound is returned when applying the objects would change the cluster. It is not printed, and only makes
+// the command exit with code 1.
+var errChangesFound = errors.New("applying the objects would change the cluster")
+
+// runDiff prints the diff of the objects and returns errChangesFound when there are differences, with the error and
+// usage output of the command silenced.
+func runDiff(ctx context.Context, cmd *cobra.Command, w io.Writer, opts kube.ApplyOptions, objects ...runtime.Object) error {
+	changed, err := diffObjects(ctx, w, opts, objects...)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return errChangesFound
+}
+
+// diffObjects prints a unified diff between the live objects and the result of applying the objects with a server-side
+// dry run, and reports whether there are differences. Objects that do not exist yet are diffed against nothing.
+func diffObjects(ctx context.Context, w io.Writer, opts kube.ApplyOptions, objects ...runtime.Object) (bool, error) {
+	opts.DryRun = true
+
+	changed := false
+	for _, obj := range objects {
+		merged, err := toPrintable(obj)
+		if err != nil {
+			return false, err
+		}
+
+		reference := objectReference(obj)
+		if _, err := kubeImpl.ApplyObject(ctx, merged, opts); err != nil {
+			return false, fmt.Errorf("failed to apply %s with a server-side dry run: %w", reference, err)
+		}
+
+		live, err := kubeImpl.GetUnstructured(ctx, merged.GroupVersionKind(), client.ObjectKeyFromObject(merged))
+		if apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return false, fmt.Errorf("failed to get %s: %w", reference, err)
+		}
+
+		diff, err := unifiedDiff(reference, live, merged)
+		if err != nil {
+			return false, err
+		}
+
+		if diff == "" {
+			continue
+		}
+
+		changed = true
+		printColoredDiff(w, diff)
+	}
+
+	return changed, nil
+}
+
+// unifiedDiff returns the diff between the live and the merged object, without the fields set by the API server. A nil
+// live object stands for an object that does not exist yet.
+func unifiedDiff(reference string, live, merged *unstructured.Unstructured) (string, error) {
+	liveYAML, err := diffableYAML(live)
+	if err != nil {
+		return "", err
+	}
+
+	mergedYAML, err := diffableYAML(merged)
+	if err != nil {
+		return "", err
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(liveYAML),
+		B:        splitLines(mergedYAML),
+		FromFile: "live/" + reference,
+		ToFile:   "merged/" + reference,
+		Context:  3,
+	})
+}
+
+// diffableYAML returns the object as YAML without the fields set by the API server and the empty SpinApp fields, which
+// are equivalent to leaving them out.
+func diffableYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	u, err := toPrintable(obj)
+	if err != nil {
+		return "", err
+	}
+
+	for _, field := range serverSetMetadataFields {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+
+	content, err := yaml.Marshal(u.Object)
+	return string(content), err
+}
+
+// splitLines splits the text into lines that keep their line break.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// printColoredDiff prints the diff with added lines in green and removed lines in red. The colors are left out when the
+// output is not a terminal.
+func printColoredDiff(w io.Writer, diff string) {
+	added := color.New(color.FgGreen)
+	removed := color.New(color.FgRed)
+	hunk := color.New(color.FgCyan)
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = color.New(color.Bold).Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			line = hunk.Sprint(line)
+		case strings.HasPrefix(line, "+"):
+			line = added.Sprint(line)
+		case strings.HasPrefix(line, "-"):
+			line = removed.Sprint(line)
+		}
+
+		fmt.Fprintln(w, line)
+	}
+}
+
+func init() {
+	diffCmd.Flags().StringSliceVarP(&diffFilenames, "filename", "f", nil, "File, directory or - for stdin with the manifests to diff. This can be specified multiple times")
+	diffCmd.Flags().BoolVarP(&diffRecursive, "recursive", "R", false, "Read the manifests in the subdirectories of the directories passed with --filename")
+	diffOwnership.addFlags(diffCmd.Flags())
+
+	if err := diffCmd.MarkFlagRequired("filename"); err != nil {
+		log.Fatal(err)
+	}
+
+	configFlags.AddFlags(diffCmd.Flags())
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestDiffObjects(t *testing.T) {
+	live, err := readManifests([]string{"-"}, false, strings.NewReader(`apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinApp
+metadata:
+  name: example-app
+  namespace: default
+spec:
+  executor: containerd-shim-spin
+  image: ghcr.io/foo/example-app:v0.1.0
+  replicas: 1
+`))
+	require.Nil(t, err)
+
+	objects, err := readManifests([]string{"-"}, false, strings.NewReader(testSpinApp+`---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  greeting: hello
+`))
+	require.Nil(t, err)
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(newScheme())).WithRuntimeObjects(live...).WithInterceptorFuncs(interceptor.Funcs{
+		// the dry run returns the applied object with the fields the API server sets
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			require.Equal(t, client.Apply, patch)
+
+			options := &client.PatchOptions{}
+			options.ApplyOptions(opts)
+			require.Equal(t, []string{metav1.DryRunAll}, options.DryRun)
+
+			obj.SetResourceVersion("162288")
+			obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: kube.FieldManager, Operation: metav1.ManagedFieldsOperationApply}})
+			return unstructured.SetNestedField(obj.(*unstructured.Unstructured).Object, int64(0), "status", "readyReplicas")
+		},
+	}).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	var output strings.Builder
+	changed, err := diffObjects(context.Background(), &output, kube.ApplyOptions{Namespace: "default"}, objects...)
+	require.Nil(t, err)
+	require.True(t, changed)
+	require.Equal(t, `--- live/spinapp.core.spinkube.dev/example-app
++++ merged/spinapp.core.spinkube.dev/example-app
+@@ -6,4 +6,4 @@
+ spec:
+   executor: containerd-shim-spin
+   image: ghcr.io/foo/example-app:v0.1.0
+-  replicas: 1
++  replicas: 2
+--- live/configmap/settings
++++ merged/configmap/settings
+@@ -0,0 +1,7 @@
++apiVersion: v1
++data:
++  greeting: hello
++kind: ConfigMap
++metadata:
++  name: settings
++  namespace: default
+`, output.String())
+
+	output.Reset()
+	changed, err = diffObjects(context.Background(), &output, kube.ApplyOptions{Namespace: "default"}, live...)
+	require.Nil(t, err)
+	require.False(t, changed)
+	require.Empty(t, output.String())
+}
+
+func TestRunDiff(t *testing.T) {
+	objects, err := readManifests([]string{"-"}, false, strings.NewReader(testSpinApp))
+	require.Nil(t, err)
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(newScheme())).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, _ client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			return nil
+		},
+	}).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	cmd := &cobra.Command{}
+	err = runDiff(context.Background(), cmd, io.Discard, kube.ApplyOptions{Namespace: "default"}, objects...)
+	require.ErrorIs(t, err, errChangesFound)
+	require.True(t, cmd.SilenceErrors)
+	require.True(t, cmd.SilenceUsage)
+
+	cmd = &cobra.Command{}
+	require.Nil(t, runDiff(context.Background(), cmd, io.Discard, kube.ApplyOptions{Namespace: "default"}))
+	require.False(t, cmd.SilenceErrors)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/spinkube/spin-plugin-kube/pkg/registry"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// imageTagAnnotation records the tag of an image that was pinned to its digest.
+const imageTagAnnotation = "spinkube.dev/image-tag"
+
+// registryHTTPClient is the HTTP client used to resolve image digests.
+var registryHTTPClient = http.DefaultClient
+
+// pinImageDigest resolves the tag of the image against its registry and returns the image reference pinned to the
+// digest, together with the original tag. The registry is authenticated with the image pull secrets of the
+// application, falling back to the local docker credentials.
+func pinImageDigest(ctx context.Context, image, secretNamespace string, pullSecrets []string) (string, string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", "", err
+	}
+
+	if ref.IsDigest() {
+		return image, "", nil
+	}
+
+	var keychain registry.MultiKeychain
+	for _, name := range pullSecrets {
+		config, err := loadPullSecret(ctx, secretNamespace, name)
+		if err != nil {
+			log.Printf("warning: skipping image pull secret %s: %v\n", name, err)
+			continue
+		}
+
+		keychain = append(keychain, config)
+	}
+
+	dockerConfig, err := registry.LoadDockerConfig()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load the docker config: %w", err)
+	}
+	keychain = append(keychain, dockerConfig)
+
+	digest, err := registry.NewClient(registryHTTPClient, keychain).ResolveDigest(ctx, image)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to pin the digest of %s: %w", image, err)
+	}
+
+	pinned, err := registry.PinDigest(image, digest)
+	if err != nil {
+		return "", "", err
+	}
+
+	return pinned, ref.Tag, nil
+}
+
+// loadPullSecret reads the docker config stored in an image pull secret of the cluster.
+func loadPullSecret(ctx context.Context, secretNamespace, name string) (*registry.DockerConfig, error) {
+	if kubeImpl == nil {
+		return nil, fmt.Errorf("no cluster connection")
+	}
+
+	secret, err := kubeImpl.GetSecret(ctx, client.ObjectKey{Namespace: secretNamespace, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return nil, fmt.Errorf("secret is not of type %s", corev1.SecretTypeDockerConfigJson)
+	}
+
+	return registry.ParseDockerConfig(data)
+}
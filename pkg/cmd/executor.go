@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/prompt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultExecutorName     = "containerd-shim-spin"
+	defaultRuntimeClassName = "wasmtime-spin-v2"
+)
+
+// ExecutorOptions are the options of a SpinAppExecutor.
+type ExecutorOptions struct {
+	caCertSecret          string
+	createDeployment      bool
+	installDefaultCACerts bool
+	name                  string
+	namespace             string
+	output                string
+	runtimeClassName      string
+	spinImage             string
+}
+
+var (
+	executorCreateOpts      = ExecutorOptions{}
+	executorCreateOwnership fieldOwnershipOptions
+	scaffoldExecutorOpts    = ExecutorOptions{}
+)
+
+func (o *ExecutorOptions) addFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.createDeployment, "create-deployment", true, "Whether the operator creates a deployment for the applications that use the executor")
+	flags.StringVar(&o.runtimeClassName, "runtime-class-name", "", fmt.Sprintf("Runtime class of the pods of the deployment. Defaults to %s unless --spin-image is set", defaultRuntimeClassName))
+	flags.StringVar(&o.spinImage, "spin-image", "", "Image that runs Spin in a container, as an alternative to a runtime class")
+	flags.BoolVar(&o.installDefaultCACerts, "install-default-ca-certs", true, "Whether the default CA certificate bundle is installed in the pods of the deployment")
+	flags.StringVar(&o.caCertSecret, "ca-cert-secret", "", "Secret with the CA certificates mounted in the pods of the deployment")
+}
+
+func validateExecutorFlags(opts ExecutorOptions) error {
+	if err := validateName("executor name", opts.name); err != nil {
+		return err
+	}
+
+	if opts.namespace != "" {
+		if err := validateName("--namespace", opts.namespace); err != nil {
+			return err
+		}
+	}
+
+	if !opts.createDeployment {
+		if opts.runtimeClassName != "" || opts.spinImage != "" || opts.caCertSecret != "" {
+			return fmt.Errorf("--runtime-class-name, --spin-image and --ca-cert-secret require --create-deployment")
+		}
+
+		return nil
+	}
+
+	if opts.runtimeClassName != "" && opts.spinImage != "" {
+		return fmt.Errorf("--runtime-class-name and --spin-image cannot be combined")
+	}
+
+	if opts.spinImage != "" && !validateImageReference(opts.spinImage) {
+		return fmt.Errorf("invalid image reference provided for --spin-image: '%s'", opts.spinImage)
+	}
+
+	if opts.caCertSecret != "" {
+		if err := validateName("--ca-cert-secret", opts.caCertSecret); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newSpinAppExecutor(opts ExecutorOptions) (*spinv1alpha1.SpinAppExecutor, error) {
+	if err := validateExecutorFlags(opts); err != nil {
+		return nil, err
+	}
+
+	executor := &spinv1alpha1.SpinAppExecutor{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: spinv1alpha1.GroupVersion.String(),
+			Kind:       "SpinAppExecutor",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.name,
+			Namespace: opts.namespace,
+		},
+		Spec: spinv1alpha1.SpinAppExecutorSpec{
+			CreateDeployment: opts.createDeployment,
+		},
+	}
+
+	if !opts.createDeployment {
+		return executor, nil
+	}
+
+	executor.Spec.DeploymentConfig = &spinv1alpha1.ExecutorDeploymentConfig{
+		CACertSecret:          opts.caCertSecret,
+		InstallDefaultCACerts: opts.installDefaultCACerts,
+	}
+
+	switch {
+	case opts.spinImage != "":
+		executor.Spec.DeploymentConfig.SpinImage = ptr(opts.spinImage)
+	case opts.runtimeClassName != "":
+		executor.Spec.DeploymentConfig.RuntimeClassName = ptr(opts.runtimeClassName)
+	default:
+		executor.Spec.DeploymentConfig.RuntimeClassName = ptr(defaultRuntimeClassName)
+	}
+
+	return executor, nil
+}
+
+func printExecutors(w io.Writer, executors ...spinv1alpha1.SpinAppExecutor) {
+	table := uitable.New()
+	table.MaxColWidth = 50
+	table.AddRow("NAMESPACE", "NAME", "CREATE DEPLOYMENT", "RUNTIME CLASS", "SPIN IMAGE")
+
+	for _, executor := range executors {
+		var runtimeClassName, spinImage string
+		if config := executor.Spec.DeploymentConfig; config != nil {
+			if config.RuntimeClassName != nil {
+				runtimeClassName = *config.RuntimeClassName
+			}
+
+			if config.SpinImage != nil {
+				spinImage = *config.SpinImage
+			}
+		}
+
+		table.AddRow(executor.Namespace, executor.Name, executor.Spec.CreateDeployment, runtimeClassName, spinImage)
+	}
+
+	fmt.Fprintln(w, table)
+}
+
+// warnIfExecutorMissing prints a warning when the executor does not exist in the namespace the application is deployed
+// to, as the operator does not run applications with a missing executor.
+func warnIfExecutorMissing(ctx context.Context, namespace, executor string) {
+	_, err := kubeImpl.GetSpinAppExecutor(ctx, client.ObjectKey{Namespace: namespace, Name: executor})
+	switch {
+	case apierrors.IsNotFound(err):
+		log.Printf("warning: the executor %s does not exist in namespace %s; create it with `spin kube executor create %s --namespace %s`\n", executor, namespace, executor, namespace)
+	case err != nil:
+		log.Printf("warning: could not check that the executor %s exists: %v\n", executor, err)
+	}
+}
+
+var executorCmd = &cobra.Command{
+	Use:    "executor",
+	Short:  "Manage SpinApp executors",
+	Hidden: isExperimentalFlagNotSet,
+}
+
+var executorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List executors",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		executors, err := kubeImpl.ListSpinAppExecutors(context.TODO(), namespace)
+		if err != nil {
+			return err
+		}
+
+		printExecutors(os.Stdout, executors.Items...)
+		return nil
+	},
+}
+
+var executorGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Display detailed executor information",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		executor, err := kubeImpl.GetSpinAppExecutor(context.TODO(), client.ObjectKey{Namespace: namespace, Name: args[0]})
+		if err != nil {
+			return err
+		}
+
+		printExecutors(os.Stdout, executor)
+		return nil
+	},
+}
+
+var executorCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create or update an executor",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		opts := executorCreateOpts
+		opts.name = args[0]
+		opts.namespace = namespace
+
+		executor, err := newSpinAppExecutor(opts)
+		if err != nil {
+			return err
+		}
+
+		return applyObjects(context.TODO(), os.Stdout, executorCreateOwnership.applyOptions(namespace), executor)
+	},
+}
+
+var executorDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete an executor",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			return err
+		}
+
+		if !yes {
+			yes, err = prompt.New(os.Stdin, os.Stdout).Confirm("Applications that use the executor will stop running. Are you sure?", false)
+			if err != nil {
+				return err
+			}
+		}
+
+		if !yes {
+			return nil
+		}
+
+		err = kubeImpl.DeleteSpinAppExecutor(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name})
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("could not find executor with name %s", name)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Successfully deleted %s\n", name)
+		return nil
+	},
+}
+
+var scaffoldExecutorCmd = &cobra.Command{
+	Use:   "executor",
+	Short: "Scaffold executor manifest",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		executor, err := newSpinAppExecutor(scaffoldExecutorOpts)
+		if err != nil {
+			return err
+		}
+
+		if scaffoldExecutorOpts.output == "" {
+			return printObjects(os.Stdout, executor)
+		}
+
+		file, err := os.OpenFile(scaffoldExecutorOpts.output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := printObjects(file, executor); err != nil {
+			return err
+		}
+
+		log.Printf("\nExecutor manifest saved to %s\n", scaffoldExecutorOpts.output)
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{executorListCmd, executorGetCmd, executorCreateCmd, executorDeleteCmd} {
+		configFlags.AddFlags(cmd.Flags())
+		executorCmd.AddCommand(cmd)
+	}
+
+	executorCreateOpts.addFlags(executorCreateCmd.Flags())
+	executorCreateOwnership.addFlags(executorCreateCmd.Flags())
+	executorDeleteCmd.Flags().BoolP("yes", "y", false, "specify --yes to immediately delete the executor")
+	rootCmd.AddCommand(executorCmd)
+
+	scaffoldExecutorOpts.addFlags(scaffoldExecutorCmd.Flags())
+	scaffoldExecutorCmd.Flags().StringVar(&scaffoldExecutorOpts.name, "name", defaultExecutorName, "Name of the executor")
+	scaffoldExecutorCmd.Flags().StringVarP(&scaffoldExecutorOpts.namespace, "namespace", "n", "", "Namespace of the executor. Defaults to the namespace it is applied to")
+	scaffoldExecutorCmd.Flags().StringVarP(&scaffoldExecutorOpts.output, "out", "o", "", "Path to file to write manifest yaml")
+	scaffoldCmd.AddCommand(scaffoldExecutorCmd)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaffoldExecutor(t *testing.T) {
+	defaults := ExecutorOptions{
+		name:                  defaultExecutorName,
+		createDeployment:      true,
+		installDefaultCACerts: true,
+	}
+
+	testcases := []struct {
+		name     string
+		opts     func(opts *ExecutorOptions)
+		expected string
+	}{
+		{
+			name: "defaults",
+			opts: func(*ExecutorOptions) {},
+			expected: `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinAppExecutor
+metadata:
+  name: containerd-shim-spin
+spec:
+  createDeployment: true
+  deploymentConfig:
+    installDefaultCACerts: true
+    runtimeClassName: wasmtime-spin-v2
+`,
+		},
+		{
+			name: "spin image",
+			opts: func(opts *ExecutorOptions) {
+				opts.name = "spin-container"
+				opts.namespace = "apps"
+				opts.spinImage = "ghcr.io/spinkube/spin:v3.0.0"
+				opts.installDefaultCACerts = false
+				opts.caCertSecret = "ca-certificates"
+			},
+			expected: `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinAppExecutor
+metadata:
+  name: spin-container
+  namespace: apps
+spec:
+  createDeployment: true
+  deploymentConfig:
+    caCertSecret: ca-certificates
+    spinImage: ghcr.io/spinkube/spin:v3.0.0
+`,
+		},
+		{
+			name: "no deployment",
+			opts: func(opts *ExecutorOptions) {
+				opts.name = "cyclotron"
+				opts.createDeployment = false
+			},
+			expected: `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinAppExecutor
+metadata:
+  name: cyclotron
+spec:
+  createDeployment: false
+`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := defaults
+			tc.opts(&opts)
+
+			executor, err := newSpinAppExecutor(opts)
+			require.Nil(t, err)
+
+			var output strings.Builder
+			require.Nil(t, printObjects(&output, executor))
+			require.Equal(t, tc.expected, output.String())
+		})
+	}
+}
+
+func TestScaffoldExecutorInvalid(t *testing.T) {
+	testcases := []struct {
+		name     string
+		opts     ExecutorOptions
+		expected string
+	}{
+		{
+			name:     "invalid name",
+			opts:     ExecutorOptions{name: "Shim", createDeployment: true},
+			expected: "invalid executor name 'Shim'",
+		},
+		{
+			name:     "runtime class and spin image",
+			opts:     ExecutorOptions{name: "shim", createDeployment: true, runtimeClassName: "wasmtime-spin-v2", spinImage: "ghcr.io/spinkube/spin:v3.0.0"},
+			expected: "--runtime-class-name and --spin-image cannot be combined",
+		},
+		{
+			name:     "invalid spin image",
+			opts:     ExecutorOptions{name: "shim", createDeployment: true, spinImage: "ghcr.io/spinkube/Spin"},
+			expected: "invalid image reference provided for --spin-image: 'ghcr.io/spinkube/Spin'",
+		},
+		{
+			name:     "deployment config without deployment",
+			opts:     ExecutorOptions{name: "shim", runtimeClassName: "wasmtime-spin-v2"},
+			expected: "--runtime-class-name, --spin-image and --ca-cert-secret require --create-deployment",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newSpinAppExecutor(tc.opts)
+			require.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
+func TestWarnIfExecutorMissing(t *testing.T) {
+	executor := &spinv1alpha1.SpinAppExecutor{
+		ObjectMeta: metav1.ObjectMeta{Name: defaultExecutorName, Namespace: "default"},
+		Spec:       spinv1alpha1.SpinAppExecutorSpec{CreateDeployment: true},
+	}
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(executor).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	var output bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&output)
+
+	warnIfExecutorMissing(context.Background(), "default", defaultExecutorName)
+	require.Empty(t, output.String())
+
+	warnIfExecutorMissing(context.Background(), "shop", defaultExecutorName)
+	require.Contains(t, output.String(), "warning: the executor containerd-shim-spin does not exist in namespace shop; create it with `spin kube executor create containerd-shim-spin --namespace shop`")
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/keda"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/printers"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	exportAll       bool
+	exportOutputDir string
+)
+
+// exportedAutoscalerKinds are the kinds of the autoscaler named `<name>-autoscaler` that is exported with an app.
+var exportedAutoscalerKinds = []schema.GroupVersionKind{
+	autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler"),
+	keda.GroupVersion.WithKind("ScaledObject"),
+	keda.HTTPGroupVersion.WithKind("HTTPScaledObject"),
+}
+
+// serverSetMetadataFields are the metadata fields set by the API server, which are not part of the desired state.
+var serverSetMetadataFields = []string{"creationTimestamp", "generation", "managedFields", "resourceVersion", "selfLink", "uid"}
+
+// serverSetAnnotations are the annotations set by kubectl and the operators, which are not part of the desired state.
+var serverSetAnnotations = []string{"kubectl.kubernetes.io/last-applied-configuration"}
+
+// healthProbeDefaults are the values the SpinApp CRD sets on health probes that do not specify them.
+var healthProbeDefaults = map[string]int64{
+	"initialDelaySeconds": 10,
+	"timeoutSeconds":      1,
+	"periodSeconds":       10,
+	"successThreshold":    1,
+	"failureThreshold":    3,
+}
+
+var exportCmd = &cobra.Command{
+	Use:    "export <name>",
+	Short:  "Export a deployed application into a manifest",
+	Hidden: isExperimentalFlagNotSet,
+	RunE: func(_ *cobra.Command, args []string) error {
+		if exportAll {
+			if len(args) > 0 {
+				return fmt.Errorf("a name cannot be specified together with --all")
+			}
+
+			if exportOutputDir == "" {
+				return fmt.Errorf("--all requires --output-dir")
+			}
+
+			count, err := exportAllApps(context.TODO(), namespace, exportOutputDir)
+			if err != nil {
+				return err
+			}
+
+			log.Printf("\nExported %d applications to %s\n", count, exportOutputDir)
+			return nil
+		}
+
+		var appName string
+		if len(args) > 0 {
+			appName = args[0]
+		}
+
+		if appName == "" && appNameFromCurrentDirContext != "" {
+			appName = appNameFromCurrentDirContext
+		}
+
+		if appName == "" {
+			return fmt.Errorf("the name of the application is required")
+		}
+
+		return exportApp(context.TODO(), os.Stdout, client.ObjectKey{Namespace: namespace, Name: appName})
+	},
+}
+
+// exportApp writes the SpinApp with its runtime config Secret and autoscaler to w, as a multi-document YAML manifest
+// that only contains the desired state of the objects.
+func exportApp(ctx context.Context, w io.Writer, key client.ObjectKey) error {
+	app, err := kubeImpl.GetSpinApp(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	objects, err := collectExportedObjects(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	printer := printers.YAMLPrinter{}
+	for _, obj := range objects {
+		if err := printer.PrintObj(obj, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportAllApps exports every SpinApp in the namespace to `<dir>/<name>.yaml` and returns the number of exported apps.
+func exportAllApps(ctx context.Context, namespace, dir string) (int, error) {
+	apps, err := kubeImpl.ListSpinApps(ctx, namespace)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	for _, app := range apps.Items {
+		var content strings.Builder
+		if err := exportApp(ctx, &content, client.ObjectKeyFromObject(&app)); err != nil {
+			return 0, fmt.Errorf("failed to export %s: %w", app.Name, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, app.Name+".yaml"), []byte(content.String()), 0644); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(apps.Items), nil
+}
+
+// collectExportedObjects returns the cleaned SpinApp, followed by the runtime config Secret it references and its
+// autoscaler, if they exist.
+func collectExportedObjects(ctx context.Context, app spinv1alpha1.SpinApp) ([]*unstructured.Unstructured, error) {
+	// typed objects read from the cluster have an empty type meta
+	app.SetGroupVersionKind(spinv1alpha1.GroupVersion.WithKind("SpinApp"))
+	spinapp, err := toPrintable(&app)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanExportedObject(spinapp)
+	removeSpinAppDefaults(spinapp)
+	objects := []*unstructured.Unstructured{spinapp}
+
+	if secretName := app.Spec.RuntimeConfig.LoadFromSecret; secretName != "" {
+		secret, err := kubeImpl.GetSecret(ctx, client.ObjectKey{Namespace: app.Namespace, Name: secretName})
+		switch {
+		case apierrors.IsNotFound(err):
+			log.Printf("warning: the runtime config secret %s of %s does not exist\n", secretName, app.Name)
+		case err != nil:
+			return nil, fmt.Errorf("failed to get the runtime config secret %s: %w", secretName, err)
+		default:
+			secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+			u, err := toPrintable(&secret)
+			if err != nil {
+				return nil, err
+			}
+
+			cleanExportedObject(u)
+			objects = append(objects, u)
+		}
+	}
+
+	for _, gvk := range exportedAutoscalerKinds {
+		autoscaler, err := kubeImpl.GetUnstructured(ctx, gvk, client.ObjectKey{Namespace: app.Namespace, Name: autoscalerName(app.Name)})
+		// the KEDA resources are missing from clusters without KEDA
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %s %s: %w", gvk.Kind, autoscalerName(app.Name), err)
+		}
+
+		unstructured.RemoveNestedField(autoscaler.Object, "status")
+		cleanExportedObject(autoscaler)
+		objects = append(objects, autoscaler)
+	}
+
+	return objects, nil
+}
+
+// cleanExportedObject removes the metadata the API server and the controllers set on the object.
+func cleanExportedObject(u *unstructured.Unstructured) {
+	for _, field := range serverSetMetadataFields {
+		unstructured.RemoveNestedField(u.Object, "metadata", field)
+	}
+
+	unstructured.RemoveNestedField(u.Object, "metadata", "finalizers")
+	unstructured.RemoveNestedField(u.Object, "metadata", "ownerReferences")
+
+	annotations := u.GetAnnotations()
+	for _, annotation := range serverSetAnnotations {
+		delete(annotations, annotation)
+	}
+
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+	} else {
+		u.SetAnnotations(annotations)
+	}
+}
+
+// removeSpinAppDefaults removes the values the SpinApp CRD sets by default, so that the exported manifest matches the
+// one it was created from.
+func removeSpinAppDefaults(u *unstructured.Unstructured) {
+	for _, probe := range []string{"liveness", "readiness"} {
+		for field, value := range healthProbeDefaults {
+			if actual, found, _ := unstructured.NestedInt64(u.Object, "spec", "checks", probe, field); found && actual == value {
+				unstructured.RemoveNestedField(u.Object, "spec", "checks", probe, field)
+			}
+		}
+	}
+
+	if enabled, _, _ := unstructured.NestedBool(u.Object, "spec", "enableAutoscaling"); !enabled {
+		unstructured.RemoveNestedField(u.Object, "spec", "enableAutoscaling")
+	}
+}
+
+func init() {
+	exportCmd.Flags().BoolVar(&exportAll, "all", false, "Export every application in the namespace into --output-dir, one file per application")
+	exportCmd.Flags().StringVar(&exportOutputDir, "output-dir", "", "Directory the applications are exported to with --all")
+
+	configFlags.AddFlags(exportCmd.Flags())
+	rootCmd.AddCommand(exportCmd)
+}
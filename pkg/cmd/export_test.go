@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/kube"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExport(t *testing.T) {
+	serverMetadata := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             "2e12ddd1-919d-44b5-b6cc-c3cd5c09fcec",
+			ResourceVersion: "162287",
+			Generation:      3,
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "spin-plugin-kube", Operation: metav1.ManagedFieldsOperationApply}},
+		}
+	}
+
+	app := &spinv1alpha1.SpinApp{
+		ObjectMeta: serverMetadata("example-app"),
+		Spec: spinv1alpha1.SpinAppSpec{
+			Image:             "ghcr.io/foo/example-app:v0.1.0",
+			Executor:          "containerd-shim-spin",
+			EnableAutoscaling: true,
+			Checks: spinv1alpha1.HealthChecks{
+				Liveness: &spinv1alpha1.HealthProbe{
+					HTTPGet:             &spinv1alpha1.HTTPHealthProbe{Path: "/healthz", HTTPHeaders: []spinv1alpha1.HTTPHealthProbeHeader{}},
+					InitialDelaySeconds: 10,
+					TimeoutSeconds:      1,
+					PeriodSeconds:       30,
+					SuccessThreshold:    1,
+					FailureThreshold:    3,
+				},
+			},
+			RuntimeConfig: spinv1alpha1.RuntimeConfig{LoadFromSecret: "example-app-runtime-config"},
+		},
+		Status: spinv1alpha1.SpinAppStatus{ReadyReplicas: 2},
+	}
+	app.Annotations["team"] = "platform"
+
+	secret := &corev1.Secret{
+		ObjectMeta: serverMetadata("example-app-runtime-config"),
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"runtime-config.toml": []byte("[key_value_store.default]\ntype = \"redis\"\n")},
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: serverMetadata("example-app-autoscaler"),
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "example-app"},
+			MinReplicas:    ptr(int32(2)),
+			MaxReplicas:    5,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2},
+	}
+
+	other := &spinv1alpha1.SpinApp{
+		ObjectMeta: serverMetadata("other-app"),
+		Spec: spinv1alpha1.SpinAppSpec{
+			Image:    "ghcr.io/foo/other-app:v0.1.0",
+			Executor: "containerd-shim-spin",
+			Replicas: 1,
+		},
+	}
+
+	kubeImpl = kube.New(fake.NewClientBuilder().WithScheme(newScheme()).WithObjects(app, secret, hpa, other).Build(), nil)
+	defer func() { kubeImpl = nil }()
+
+	var output strings.Builder
+	require.Nil(t, exportApp(context.Background(), &output, client.ObjectKey{Namespace: "default", Name: "example-app"}))
+
+	requireManifestsEqual(t, `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinApp
+metadata:
+  annotations:
+    team: platform
+  name: example-app
+  namespace: default
+spec:
+  checks:
+    liveness:
+      httpGet:
+        httpHeaders: []
+        path: /healthz
+      periodSeconds: 30
+  enableAutoscaling: true
+  executor: containerd-shim-spin
+  image: ghcr.io/foo/example-app:v0.1.0
+  runtimeConfig:
+    loadFromSecret: example-app-runtime-config
+---
+apiVersion: v1
+data:
+  runtime-config.toml: W2tleV92YWx1ZV9zdG9yZS5kZWZhdWx0XQp0eXBlID0gInJlZGlzIgo=
+kind: Secret
+metadata:
+  name: example-app-runtime-config
+  namespace: default
+type: Opaque
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: example-app-autoscaler
+  namespace: default
+spec:
+  maxReplicas: 5
+  minReplicas: 2
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: example-app
+`, output.String())
+
+	dir := t.TempDir()
+	count, err := exportAllApps(context.Background(), "default", dir)
+	require.Nil(t, err)
+	require.Equal(t, 2, count)
+
+	content, err := os.ReadFile(filepath.Join(dir, "example-app.yaml"))
+	require.Nil(t, err)
+	require.Equal(t, output.String(), string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "other-app.yaml"))
+	require.Nil(t, err)
+	requireManifestsEqual(t, `apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinApp
+metadata:
+  name: other-app
+  namespace: default
+spec:
+  executor: containerd-shim-spin
+  image: ghcr.io/foo/other-app:v0.1.0
+  replicas: 1
+`, string(content))
+}
@@ -19,18 +19,23 @@ func NewCommandFactory() (cmdutil.Factory, genericclioptions.IOStreams) {
 		genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
 }
 
-func getRuntimeClient() (client.Client, error) {
+// newScheme returns the scheme with the built-in Kubernetes types and the SpinKube types.
+func newScheme() *runtime.Scheme {
 	var scheme = runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(spinv1alpha1.AddToScheme(scheme))
 
+	return scheme
+}
+
+func getRuntimeClient() (client.Client, error) {
 	config, err := configFlags.ToRESTConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	return client.New(config, client.Options{
-		Scheme: scheme,
+		Scheme: newScheme(),
 	})
 }
 
@@ -0,0 +1,423 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerparser "github.com/novln/docker-parser"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// helmChartVersion is the version of newly generated charts.
+const helmChartVersion = "0.1.0"
+
+// helmChart is the content of Chart.yaml.
+type helmChart struct {
+	APIVersion  string `json:"apiVersion"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion"`
+}
+
+// helmValues is the content of values.yaml. The runtime config is only part of the values when it is loaded from a
+// file; typed key value stores, databases and LLM compute are rendered as is.
+type helmValues struct {
+	Image         string                 `json:"image"`
+	Replicas      int32                  `json:"replicas"`
+	Resources     spinv1alpha1.Resources `json:"resources"`
+	Autoscaler    *helmAutoscalerValues  `json:"autoscaler,omitempty"`
+	Variables     []spinv1alpha1.SpinVar `json:"variables"`
+	RuntimeConfig *string                `json:"runtimeConfig,omitempty"`
+}
+
+type helmAutoscalerValues struct {
+	Enabled                           bool   `json:"enabled"`
+	MaxReplicas                       int32  `json:"maxReplicas"`
+	TargetCPUUtilizationPercentage    *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+}
+
+// writeHelmChart writes a Helm chart to dir that renders the given objects with its default values. The image, the
+// replicas, the resources, the autoscaler settings, the variables and the runtime config are parameterized; everything
+// else is rendered as is.
+func writeHelmChart(dir string, config appConfig, objects []runtime.Object) error {
+	values := helmValues{
+		Image:     config.Image,
+		Replicas:  config.Replicas,
+		Variables: config.Variables,
+	}
+
+	if values.Variables == nil {
+		values.Variables = []spinv1alpha1.SpinVar{}
+	}
+
+	if config.RuntimeConfig != nil {
+		values.RuntimeConfig = ptr(string(config.RuntimeConfig))
+	}
+
+	if config.Autoscaler != "" {
+		values.Autoscaler = &helmAutoscalerValues{
+			Enabled:     true,
+			MaxReplicas: config.MaxReplicas,
+		}
+
+		if config.Autoscaler != "keda-http" && !config.DisableCPUAutoscaling {
+			values.Autoscaler.TargetCPUUtilizationPercentage = ptr(config.TargetCPUUtilizationPercentage)
+		}
+
+		if config.Autoscaler != "keda-http" && !config.DisableMemoryAutoscaling {
+			values.Autoscaler.TargetMemoryUtilizationPercentage = ptr(config.TargetMemoryUtilizationPercentage)
+		}
+	}
+
+	templates := map[string]string{}
+
+	for _, obj := range objects {
+		u, err := toPrintable(obj)
+		if err != nil {
+			return err
+		}
+
+		var template string
+		switch u.GetKind() {
+		case "SpinApp":
+			if app, ok := obj.(*spinv1alpha1.SpinApp); ok {
+				values.Resources = app.Spec.Resources
+			}
+			template, err = newSpinAppTemplate(u, config)
+		case "Secret":
+			template, err = newRuntimeConfigSecretTemplate(u)
+		case "HorizontalPodAutoscaler":
+			template, err = newHorizontalPodAutoscalerTemplate(u)
+		case "ScaledObject":
+			template, err = newScaledObjectTemplate(u)
+		case "HTTPScaledObject":
+			template, err = newHTTPScaledObjectTemplate(u)
+		case "TriggerAuthentication":
+			template, err = newAutoscalerTemplate(u)
+		case "Service":
+			// the only service generated is the KEDA HTTP add-on interceptor
+			template, err = newAutoscalerTemplate(u)
+		default:
+			template, err = marshalYAML(u.Object, 0)
+		}
+		if err != nil {
+			return err
+		}
+
+		templates[objectFileName(u)] = template
+	}
+
+	appVersion := "latest"
+	if config.ImageTag != "" {
+		appVersion = config.ImageTag
+	} else if ref, err := dockerparser.Parse(config.Image); err == nil {
+		appVersion = ref.Tag()
+	}
+
+	chart, err := yaml.Marshal(helmChart{
+		APIVersion:  "v2",
+		Name:        config.Name,
+		Description: fmt.Sprintf("A Helm chart for the %s Spin application", config.Name),
+		Type:        "application",
+		Version:     helmChartVersion,
+		AppVersion:  appVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	valuesContent, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		"Chart.yaml":  chart,
+		"values.yaml": valuesContent,
+	}
+
+	for name, template := range templates {
+		files[filepath.Join("templates", name)] = []byte(template)
+	}
+
+	for _, name := range sortedKeys(files) {
+		if err := os.WriteFile(filepath.Join(dir, name), files[name], 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newSpinAppTemplate renders the SpinApp with the parameterized fields taken from the values.
+func newSpinAppTemplate(u *unstructured.Unstructured, config appConfig) (string, error) {
+	for _, field := range []string{"image", "replicas", "enableAutoscaling", "resources", "variables"} {
+		unstructured.RemoveNestedField(u.Object, "spec", field)
+	}
+
+	// a runtime config file is the only runtime config when it is set
+	if config.RuntimeConfig != nil {
+		unstructured.RemoveNestedField(u.Object, "spec", "runtimeConfig")
+	}
+
+	var template strings.Builder
+	if err := writeSpec(&template, u); err != nil {
+		return "", err
+	}
+
+	template.WriteString("  image: {{ .Values.image | quote }}\n")
+
+	if config.Autoscaler != "" {
+		template.WriteString(`{{- if .Values.autoscaler.enabled }}
+  enableAutoscaling: true
+{{- else }}
+  replicas: {{ .Values.replicas }}
+{{- end }}
+`)
+	} else {
+		template.WriteString("  replicas: {{ .Values.replicas }}\n")
+	}
+
+	template.WriteString(`{{- with .Values.resources }}
+  resources:
+    {{- toYaml . | nindent 4 }}
+{{- end }}
+{{- with .Values.variables }}
+  variables:
+    {{- toYaml . | nindent 4 }}
+{{- end }}
+`)
+
+	if config.RuntimeConfig != nil {
+		fmt.Fprintf(&template, `{{- if .Values.runtimeConfig }}
+  runtimeConfig:
+    loadFromSecret: %s
+{{- end }}
+`, runtimeConfigSecretName(config.Name))
+	}
+
+	return template.String(), nil
+}
+
+// newRuntimeConfigSecretTemplate renders the runtime config Secret from the runtimeConfig value.
+func newRuntimeConfigSecretTemplate(u *unstructured.Unstructured) (string, error) {
+	unstructured.RemoveNestedField(u.Object, "data")
+
+	static, err := marshalYAML(u.Object, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`{{- if .Values.runtimeConfig }}
+%sdata:
+  %s: {{ .Values.runtimeConfig | b64enc | quote }}
+{{- end }}
+`, static, runtimeConfigSecretKey), nil
+}
+
+// newHorizontalPodAutoscalerTemplate renders the HorizontalPodAutoscaler with the replicas and the cpu and memory
+// targets taken from the values. Additional metrics are rendered as is.
+func newHorizontalPodAutoscalerTemplate(u *unstructured.Unstructured) (string, error) {
+	metrics, _, _ := unstructured.NestedSlice(u.Object, "spec", "metrics")
+	for _, field := range []string{"minReplicas", "maxReplicas", "metrics"} {
+		unstructured.RemoveNestedField(u.Object, "spec", field)
+	}
+
+	var template strings.Builder
+	if err := writeSpec(&template, u); err != nil {
+		return "", err
+	}
+
+	template.WriteString(`  minReplicas: {{ .Values.replicas }}
+  maxReplicas: {{ .Values.autoscaler.maxReplicas }}
+  metrics:
+  {{- with .Values.autoscaler.targetCPUUtilizationPercentage }}
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: {{ . }}
+  {{- end }}
+  {{- with .Values.autoscaler.targetMemoryUtilizationPercentage }}
+  - type: Resource
+    resource:
+      name: memory
+      target:
+        type: Utilization
+        averageUtilization: {{ . }}
+  {{- end }}
+`)
+
+	if err := writeItems(&template, metrics, func(metric map[string]any) bool {
+		name, _, _ := unstructured.NestedString(metric, "resource", "name")
+		return metric["type"] == "Resource" && (name == "cpu" || name == "memory")
+	}); err != nil {
+		return "", err
+	}
+
+	return wrapAutoscalerTemplate(template.String()), nil
+}
+
+// newScaledObjectTemplate renders the ScaledObject with the replicas and the cpu and memory triggers taken from the
+// values. Event-driven triggers are rendered as is.
+func newScaledObjectTemplate(u *unstructured.Unstructured) (string, error) {
+	triggers, _, _ := unstructured.NestedSlice(u.Object, "spec", "triggers")
+	for _, field := range []string{"minReplicaCount", "maxReplicaCount", "triggers"} {
+		unstructured.RemoveNestedField(u.Object, "spec", field)
+	}
+
+	var template strings.Builder
+	if err := writeSpec(&template, u); err != nil {
+		return "", err
+	}
+
+	template.WriteString(`  minReplicaCount: {{ .Values.replicas }}
+  maxReplicaCount: {{ .Values.autoscaler.maxReplicas }}
+  triggers:
+  {{- with .Values.autoscaler.targetCPUUtilizationPercentage }}
+  - type: cpu
+    metricType: Utilization
+    metadata:
+      value: {{ . | quote }}
+  {{- end }}
+  {{- with .Values.autoscaler.targetMemoryUtilizationPercentage }}
+  - type: memory
+    metricType: Utilization
+    metadata:
+      value: {{ . | quote }}
+  {{- end }}
+`)
+
+	if err := writeItems(&template, triggers, func(trigger map[string]any) bool {
+		return trigger["type"] == "cpu" || trigger["type"] == "memory"
+	}); err != nil {
+		return "", err
+	}
+
+	return wrapAutoscalerTemplate(template.String()), nil
+}
+
+// newHTTPScaledObjectTemplate renders the HTTPScaledObject with the replicas taken from the values.
+func newHTTPScaledObjectTemplate(u *unstructured.Unstructured) (string, error) {
+	unstructured.RemoveNestedField(u.Object, "spec", "replicas")
+
+	var template strings.Builder
+	if err := writeSpec(&template, u); err != nil {
+		return "", err
+	}
+
+	template.WriteString(`  replicas:
+    min: {{ .Values.replicas }}
+    max: {{ .Values.autoscaler.maxReplicas }}
+`)
+
+	return wrapAutoscalerTemplate(template.String()), nil
+}
+
+// newAutoscalerTemplate renders an object that is only needed when autoscaling is enabled.
+func newAutoscalerTemplate(u *unstructured.Unstructured) (string, error) {
+	static, err := marshalYAML(u.Object, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return wrapAutoscalerTemplate(static), nil
+}
+
+func wrapAutoscalerTemplate(template string) string {
+	return "{{- if .Values.autoscaler.enabled }}\n" + template + "{{- end }}\n"
+}
+
+// writeSpec writes the object without its spec, followed by the `spec:` key and the remaining spec fields, so the
+// parameterized spec fields can be appended.
+func writeSpec(template *strings.Builder, u *unstructured.Unstructured) error {
+	spec, _, _ := unstructured.NestedMap(u.Object, "spec")
+	unstructured.RemoveNestedField(u.Object, "spec")
+
+	static, err := marshalYAML(u.Object, 0)
+	if err != nil {
+		return err
+	}
+
+	template.WriteString(static)
+	template.WriteString("spec:\n")
+
+	if len(spec) > 0 {
+		staticSpec, err := marshalYAML(spec, 2)
+		if err != nil {
+			return err
+		}
+
+		template.WriteString(staticSpec)
+	}
+
+	return nil
+}
+
+// writeItems appends the list items that are not skipped, indented to continue a list under a spec field.
+func writeItems(template *strings.Builder, items []any, skip func(map[string]any) bool) error {
+	var static []any
+	for _, item := range items {
+		if fields, ok := item.(map[string]any); ok && skip(fields) {
+			continue
+		}
+
+		static = append(static, item)
+	}
+
+	if len(static) == 0 {
+		return nil
+	}
+
+	content, err := marshalYAML(static, 2)
+	if err != nil {
+		return err
+	}
+
+	template.WriteString(content)
+	return nil
+}
+
+// marshalYAML marshals the value to YAML for a template, indenting every line by the given number of spaces. Template
+// delimiters in the values, e.g. in annotations or KEDA trigger metadata, are escaped so that Helm renders them as is.
+func marshalYAML(value any, indent int) (string, error) {
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	escaped := escapeTemplateDelimiters(string(content))
+	if indent == 0 {
+		return escaped, nil
+	}
+
+	var indented bytes.Buffer
+	prefix := strings.Repeat(" ", indent)
+	for _, line := range strings.SplitAfter(escaped, "\n") {
+		if line != "" {
+			indented.WriteString(prefix + line)
+		}
+	}
+
+	return indented.String(), nil
+}
+
+// escapeTemplateDelimiters replaces every {{ with an action that prints it, which is enough for the text to be
+// rendered unchanged since }} outside of an action is plain text.
+func escapeTemplateDelimiters(text string) string {
+	return strings.ReplaceAll(text, "{{", `{{"{{"}}`)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/go-task/slim-sprig"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestHelmChart(t *testing.T) {
+	testcases := []struct {
+		name string
+		opts ScaffoldOptions
+	}{
+		{
+			name: "HPA autoscaler with variables and runtime config",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				executor:                          "containerd-shim-spin",
+				autoscaler:                        "hpa",
+				cpuLimit:                          "100m",
+				memoryLimit:                       "128Mi",
+				cpuRequest:                        "50m",
+				replicas:                          2,
+				maxReplicas:                       5,
+				targetCPUUtilizationPercentage:    60,
+				targetMemoryUtilizationPercentage: 70,
+				hpaMetrics:                        []string{"type=pods,name=http_requests_per_second,averageValue=100"},
+				hpaScaleDownStabilizationWindow:   ptr[int32](600),
+				variables:                         map[string]string{"greeting": "hello", "template": "{{ .Release.Name }}"},
+				variablesFromSecret:               map[string]string{"password": "db-creds:password"},
+				configfile:                        "testdata/runtime-config.toml",
+			},
+		},
+		{
+			name: "KEDA autoscaler with event triggers",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				executor:                          "containerd-shim-spin",
+				autoscaler:                        "keda",
+				cpuLimit:                          "100m",
+				replicas:                          0,
+				maxReplicas:                       10,
+				targetCPUUtilizationPercentage:    60,
+				disableMemoryAutoscaling:          true,
+				kedaTriggers:                      []string{"type=redis,address=redis:6379,listName={{jobs}},listLength=10"},
+				kedaTriggerAuth:                   map[string]string{"password": "redis-creds:password"},
+				targetMemoryUtilizationPercentage: 60,
+			},
+		},
+		{
+			name: "KEDA HTTP autoscaler",
+			opts: ScaffoldOptions{
+				from:                          "ghcr.io/foo/example-app:v0.1.0",
+				executor:                      "containerd-shim-spin",
+				autoscaler:                    "keda-http",
+				maxReplicas:                   10,
+				kedaHTTPHosts:                 []string{"example.com"},
+				kedaHTTPTargetPendingRequests: 100,
+				kedaHTTPScaledownPeriod:       300,
+				kedaHTTPInterceptorNamespace:  "keda",
+			},
+		},
+		{
+			name: "no autoscaler with stores, volumes and labels",
+			opts: ScaffoldOptions{
+				from:                   "ghcr.io/foo/example-app:v0.1.0",
+				executor:               "containerd-shim-spin",
+				replicas:               2,
+				keyValueStores:         []string{"default=redis,url=redis://redis:6379"},
+				volumes:                []string{"data=pvc:example-app-data"},
+				volumeMounts:           []string{"data:/data"},
+				persistentVolumeClaims: []string{"example-app-data=1Gi"},
+				metadata: metadataOptions{
+					labels:      map[string]string{"team": "platform"},
+					annotations: map[string]string{"example.com/template": "{{ .Values.image }} {{{ }}"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected, err := scaffold(tc.opts)
+			require.Nil(t, err)
+
+			tc.opts.helmChart = t.TempDir()
+			require.Nil(t, scaffoldHelmChart(tc.opts))
+
+			chart, err := os.ReadFile(filepath.Join(tc.opts.helmChart, "Chart.yaml"))
+			require.Nil(t, err)
+			require.YAMLEq(t, `
+apiVersion: v2
+name: example-app
+description: A Helm chart for the example-app Spin application
+type: application
+version: 0.1.0
+appVersion: v0.1.0
+`, string(chart))
+
+			rendered := renderHelmChart(t, tc.opts.helmChart)
+			requireManifestsEquivalent(t, string(expected), rendered)
+		})
+	}
+}
+
+// renderHelmChart renders the chart templates with the default values, using the sprig functions and the toYaml
+// function Helm adds.
+func renderHelmChart(t *testing.T, dir string) string {
+	content, err := os.ReadFile(filepath.Join(dir, "values.yaml"))
+	require.Nil(t, err)
+
+	values := map[string]any{}
+	require.Nil(t, yaml.Unmarshal(content, &values))
+
+	funcs := sprig.TxtFuncMap()
+	funcs["toYaml"] = func(value any) string {
+		content, err := yaml.Marshal(value)
+		if err != nil {
+			return ""
+		}
+
+		return strings.TrimSuffix(string(content), "\n")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "templates", "*.yaml"))
+	require.Nil(t, err)
+
+	var docs []string
+	for _, file := range files {
+		tmpl, err := template.New(filepath.Base(file)).Option("missingkey=zero").Funcs(funcs).ParseFiles(file)
+		require.Nil(t, err)
+
+		var output strings.Builder
+		require.Nil(t, tmpl.Execute(&output, map[string]any{"Values": values}))
+
+		if doc := strings.TrimSpace(output.String()); doc != "" {
+			docs = append(docs, doc)
+		}
+	}
+
+	return strings.Join(docs, "\n---\n")
+}
+
+// requireManifestsEquivalent compares two multi-document YAML manifests, ignoring the order of the documents.
+func requireManifestsEquivalent(t *testing.T, expected, actual string) {
+	index := func(manifest string) map[string]string {
+		docs := map[string]string{}
+		for _, doc := range strings.Split(strings.TrimSpace(manifest), "\n---\n") {
+			doc = strings.TrimPrefix(doc, "---\n")
+			docs[resourceKey(t, []byte(doc))] = doc
+		}
+
+		return docs
+	}
+
+	expectedDocs := index(expected)
+	actualDocs := index(actual)
+	require.ElementsMatch(t, sortedKeys(expectedDocs), sortedKeys(actualDocs))
+
+	for key, doc := range expectedDocs {
+		require.YAMLEq(t, doc, actualDocs[key], key)
+	}
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The limits the HorizontalPodAutoscaler API enforces on scaling behavior.
+const (
+	maxHPAStabilizationWindowSeconds = 3600
+	maxHPAPolicyPeriodSeconds        = 1800
+)
+
+// optionalInt32Value is an int32 flag that stays nil until it is set, so that an explicit 0 can be told apart from the
+// flag not being set.
+type optionalInt32Value struct {
+	value **int32
+}
+
+func (v optionalInt32Value) String() string {
+	if v.value == nil || *v.value == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(int64(**v.value), 10)
+}
+
+func (v optionalInt32Value) Set(s string) error {
+	number, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+
+	*v.value = ptr(int32(number))
+	return nil
+}
+
+func (v optionalInt32Value) Type() string {
+	return "int32"
+}
+
+// parseHPAMetric parses a --hpa-metric flag of the form
+// `type=<pods|object|external>,name=<metric>,<value|averageValue>=<quantity>[,describedObject=<apiVersion>/<kind>/<name>][,selector.<label>=<value>...]`.
+func parseHPAMetric(value string) (autoscalingv2.MetricSpec, error) {
+	var (
+		metricType      string
+		identifier      autoscalingv2.MetricIdentifier
+		target          autoscalingv2.MetricTarget
+		describedObject *autoscalingv2.CrossVersionObjectReference
+	)
+
+	pairs, err := splitKeyValuePairs(value)
+	if err != nil {
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; %w", value, err)
+	}
+
+	for _, pair := range pairs {
+		key, val, found := strings.Cut(pair, "=")
+		if !found || key == "" || val == "" {
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; expected type=<pods|object|external>,name=<metric>,<value|averageValue>=<quantity> but got '%s' (enclose values that contain commas in double quotes, e.g. key=\"a,b\")", value, pair)
+		}
+
+		switch {
+		case key == "type":
+			metricType = val
+		case key == "name":
+			identifier.Name = val
+		case key == "value" || key == "averageValue":
+			if target.Type != "" {
+				return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; only one of value or averageValue can be set", value)
+			}
+
+			quantity, err := resource.ParseQuantity(val)
+			if err != nil {
+				return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid %s '%s' for --hpa-metric '%s': %w", key, val, value, err)
+			}
+
+			if key == "value" {
+				target.Type = autoscalingv2.ValueMetricType
+				target.Value = &quantity
+			} else {
+				target.Type = autoscalingv2.AverageValueMetricType
+				target.AverageValue = &quantity
+			}
+		case key == "describedObject":
+			parts := strings.Split(val, "/")
+			if len(parts) < 3 || len(parts) > 4 || slices.Contains(parts, "") {
+				return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid describedObject '%s' for --hpa-metric '%s'; expected <apiVersion>/<kind>/<name>", val, value)
+			}
+
+			describedObject = &autoscalingv2.CrossVersionObjectReference{
+				APIVersion: strings.Join(parts[:len(parts)-2], "/"),
+				Kind:       parts[len(parts)-2],
+				Name:       parts[len(parts)-1],
+			}
+		case strings.HasPrefix(key, "selector."):
+			if identifier.Selector == nil {
+				identifier.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{}}
+			}
+
+			identifier.Selector.MatchLabels[strings.TrimPrefix(key, "selector.")] = val
+		default:
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; unknown key '%s'", value, key)
+		}
+	}
+
+	if identifier.Name == "" {
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; the metric name must be set with name=<metric>", value)
+	}
+
+	if target.Type == "" {
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; the target must be set with value=<quantity> or averageValue=<quantity>", value)
+	}
+
+	if metricType != "object" && describedObject != nil {
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; describedObject is only supported by object metrics", value)
+	}
+
+	switch metricType {
+	case "pods":
+		if target.Type != autoscalingv2.AverageValueMetricType {
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; pods metrics only support averageValue", value)
+		}
+
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{Metric: identifier, Target: target},
+		}, nil
+	case "object":
+		if describedObject == nil {
+			return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; object metrics require describedObject=<apiVersion>/<kind>/<name>", value)
+		}
+
+		return autoscalingv2.MetricSpec{
+			Type:   autoscalingv2.ObjectMetricSourceType,
+			Object: &autoscalingv2.ObjectMetricSource{DescribedObject: *describedObject, Metric: identifier, Target: target},
+		}, nil
+	case "external":
+		return autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: identifier, Target: target},
+		}, nil
+	case "":
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid value '%s' for --hpa-metric; the metric type must be set with type=<pods|object|external>", value)
+	default:
+		return autoscalingv2.MetricSpec{}, fmt.Errorf("invalid metric type '%s' for --hpa-metric '%s'; expected one of pods, object or external", metricType, value)
+	}
+}
+
+// parseHPAScalingPolicy parses a --hpa-scale-up-policy or --hpa-scale-down-policy flag of the form
+// `<pods|percent>=<value>,period=<seconds>`.
+func parseHPAScalingPolicy(flag, value string) (autoscalingv2.HPAScalingPolicy, error) {
+	var (
+		policy    autoscalingv2.HPAScalingPolicy
+		periodSet bool
+	)
+
+	for _, pair := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(pair, "=")
+		number, err := strconv.ParseInt(val, 10, 32)
+		if !found || err != nil {
+			return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; expected <pods|percent>=<value>,period=<seconds>", value, flag)
+		}
+
+		switch key {
+		case "pods", "percent":
+			if policy.Type != "" {
+				return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; only one of pods or percent can be set", value, flag)
+			}
+
+			policy.Type = autoscalingv2.PodsScalingPolicy
+			if key == "percent" {
+				policy.Type = autoscalingv2.PercentScalingPolicy
+			}
+			policy.Value = int32(number)
+		case "period":
+			policy.PeriodSeconds = int32(number)
+			periodSet = true
+		default:
+			return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; unknown key '%s'", value, flag, key)
+		}
+	}
+
+	if policy.Type == "" || !periodSet {
+		return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; expected <pods|percent>=<value>,period=<seconds>", value, flag)
+	}
+
+	if policy.Value < 1 {
+		return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; the policy value (%d) must be greater than 0", value, flag, policy.Value)
+	}
+
+	if policy.PeriodSeconds < 1 || policy.PeriodSeconds > maxHPAPolicyPeriodSeconds {
+		return autoscalingv2.HPAScalingPolicy{}, fmt.Errorf("invalid value '%s' for --%s; the period (%d) must be between 1 and %d seconds", value, flag, policy.PeriodSeconds, maxHPAPolicyPeriodSeconds)
+	}
+
+	return policy, nil
+}
+
+// newHPAScalingRules returns the scaling rules for one direction, or nil if neither a stabilization window nor
+// policies are set so the Kubernetes defaults apply. A window of 0 is kept, e.g. to scale up immediately.
+func newHPAScalingRules(flag string, stabilizationWindow *int32, policies []string) (*autoscalingv2.HPAScalingRules, error) {
+	if stabilizationWindow == nil && len(policies) == 0 {
+		return nil, nil
+	}
+
+	rules := &autoscalingv2.HPAScalingRules{
+		StabilizationWindowSeconds: stabilizationWindow,
+	}
+
+	for _, value := range policies {
+		policy, err := parseHPAScalingPolicy(flag, value)
+		if err != nil {
+			return nil, err
+		}
+
+		rules.Policies = append(rules.Policies, policy)
+	}
+
+	return rules, nil
+}
+
+// newHPABehavior returns the scaling behavior configured by the --hpa-scale-* flags, or nil if none are set.
+func newHPABehavior(opts ScaffoldOptions) (*autoscalingv2.HorizontalPodAutoscalerBehavior, error) {
+	scaleUp, err := newHPAScalingRules("hpa-scale-up-policy", opts.hpaScaleUpStabilizationWindow, opts.hpaScaleUpPolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	scaleDown, err := newHPAScalingRules("hpa-scale-down-policy", opts.hpaScaleDownStabilizationWindow, opts.hpaScaleDownPolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	if scaleUp == nil && scaleDown == nil {
+		return nil, nil
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscalerBehavior{ScaleUp: scaleUp, ScaleDown: scaleDown}, nil
+}
+
+// parseHPAMetrics parses the --hpa-metric flags.
+func parseHPAMetrics(opts ScaffoldOptions) ([]autoscalingv2.MetricSpec, error) {
+	var metrics []autoscalingv2.MetricSpec
+	for _, value := range opts.hpaMetrics {
+		metric, err := parseHPAMetric(value)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, nil
+}
+
+func validateHPAFlags(opts ScaffoldOptions) error {
+	if opts.autoscaler != "hpa" {
+		if len(opts.hpaMetrics) > 0 || len(opts.hpaScaleUpPolicies) > 0 || len(opts.hpaScaleDownPolicies) > 0 ||
+			opts.hpaScaleUpStabilizationWindow != nil || opts.hpaScaleDownStabilizationWindow != nil {
+			return fmt.Errorf("--hpa-metric and the --hpa-scale-* flags require --autoscaler hpa")
+		}
+
+		return nil
+	}
+
+	for _, window := range []struct {
+		flag  string
+		value *int32
+	}{
+		{"hpa-scale-up-stabilization-window", opts.hpaScaleUpStabilizationWindow},
+		{"hpa-scale-down-stabilization-window", opts.hpaScaleDownStabilizationWindow},
+	} {
+		if window.value != nil && (*window.value < 0 || *window.value > maxHPAStabilizationWindowSeconds) {
+			return fmt.Errorf("--%s (%d) must be between 0 and %d seconds", window.flag, *window.value, maxHPAStabilizationWindowSeconds)
+		}
+	}
+
+	if _, err := newHPABehavior(opts); err != nil {
+		return err
+	}
+
+	metrics, err := parseHPAMetrics(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.disableCPUAutoscaling && opts.disableMemoryAutoscaling && len(metrics) == 0 {
+		return fmt.Errorf("at least one --hpa-metric is required when both cpu and memory autoscaling are disabled")
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spinkube/spin-plugin-kube/pkg/keda"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kedaScalerRequiredMetadata lists the metadata keys required by the common KEDA scalers. Each entry is a set of
+// alternatives, at least one of which must be provided either as trigger metadata or as a --keda-trigger-auth
+// parameter. Other scaler types are passed through without validation.
+var kedaScalerRequiredMetadata = map[string][][]string{
+	"redis":      {{"address", "host"}, {"listName"}},
+	"kafka":      {{"bootstrapServers"}, {"consumerGroup"}},
+	"prometheus": {{"serverAddress"}, {"query"}, {"threshold"}},
+	"cron":       {{"timezone"}, {"start"}, {"end"}, {"desiredReplicas"}},
+	"rabbitmq":   {{"queueName"}, {"host", "hostFromEnv"}},
+}
+
+// parseKedaTrigger parses a --keda-trigger flag of the form `type=<scaler>[,name=<name>][,metricType=<type>],key=value...`.
+// All keys other than type, name and metricType are passed to the scaler as metadata. Values that contain commas, such
+// as a list of Kafka brokers, must be enclosed in double quotes.
+func parseKedaTrigger(value string) (keda.ScaleTriggers, error) {
+	trigger := keda.ScaleTriggers{Metadata: map[string]string{}}
+
+	pairs, err := splitKeyValuePairs(value)
+	if err != nil {
+		return keda.ScaleTriggers{}, fmt.Errorf("invalid value '%s' for --keda-trigger; %w", value, err)
+	}
+
+	for _, pair := range pairs {
+		key, val, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return keda.ScaleTriggers{}, fmt.Errorf("invalid value '%s' for --keda-trigger; expected type=<scaler>,key=value... but got '%s' (enclose values that contain commas in double quotes, e.g. key=\"a,b\")", value, pair)
+		}
+
+		switch key {
+		case "type":
+			trigger.Type = val
+		case "name":
+			trigger.Name = val
+		case "metricType":
+			trigger.MetricType = val
+		default:
+			trigger.Metadata[key] = val
+		}
+	}
+
+	if trigger.Type == "" {
+		return keda.ScaleTriggers{}, fmt.Errorf("invalid value '%s' for --keda-trigger; the scaler type must be set with type=<scaler>", value)
+	}
+
+	if trigger.Type == "cpu" || trigger.Type == "memory" {
+		return keda.ScaleTriggers{}, fmt.Errorf("invalid value '%s' for --keda-trigger; %s triggers are configured with --autoscaler-target-%s-utilization", value, trigger.Type, trigger.Type)
+	}
+
+	return trigger, nil
+}
+
+// validateKedaTrigger checks that all the metadata required by the scaler is provided, either directly or through the
+// trigger authentication parameters.
+func validateKedaTrigger(trigger keda.ScaleTriggers, authParameters []string) error {
+	for _, alternatives := range kedaScalerRequiredMetadata[trigger.Type] {
+		provided := slices.ContainsFunc(alternatives, func(key string) bool {
+			_, ok := trigger.Metadata[key]
+			return ok || slices.Contains(authParameters, key)
+		})
+
+		if !provided {
+			return fmt.Errorf("the %s trigger requires the '%s' metadata", trigger.Type, strings.Join(alternatives, "' or '"))
+		}
+	}
+
+	return nil
+}
+
+// parseKedaTriggers parses and validates the --keda-trigger flags.
+func parseKedaTriggers(opts ScaffoldOptions) ([]keda.ScaleTriggers, error) {
+	var triggers []keda.ScaleTriggers
+	for _, value := range opts.kedaTriggers {
+		trigger, err := parseKedaTrigger(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validateKedaTrigger(trigger, sortedKeys(opts.kedaTriggerAuth)); err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for --keda-trigger: %w", value, err)
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	return triggers, nil
+}
+
+func triggerAuthenticationName(appName string) string {
+	return derivedName(appName, "trigger-auth")
+}
+
+// newTriggerAuthentication returns a TriggerAuthentication with the secret references given as parameter=secretName:key
+// pairs.
+func newTriggerAuthentication(config appConfig) (*keda.TriggerAuthentication, error) {
+	auth := &keda.TriggerAuthentication{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: keda.GroupVersion.String(),
+			Kind:       "TriggerAuthentication",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: triggerAuthenticationName(config.Name),
+		},
+	}
+
+	for _, parameter := range sortedKeys(config.KedaTriggerAuth) {
+		secretName, key, err := parseKeyReference(config.KedaTriggerAuth[parameter])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for --keda-trigger-auth parameter '%s'; expected parameter=secretName:key", config.KedaTriggerAuth[parameter], parameter)
+		}
+
+		auth.Spec.SecretTargetRef = append(auth.Spec.SecretTargetRef, keda.AuthSecretTargetRef{
+			Parameter: parameter,
+			Name:      secretName,
+			Key:       key,
+		})
+	}
+
+	return auth, nil
+}
+
+const (
+	// kedaHTTPInterceptorService is the service of the KEDA HTTP add-on that routes requests to scaled applications.
+	kedaHTTPInterceptorService = "keda-add-ons-http-interceptor-proxy"
+	kedaHTTPInterceptorPort    = 8080
+)
+
+// newHTTPScaledObject returns an HTTPScaledObject that scales the SpinApp's Deployment based on the HTTP requests
+// pending in the KEDA HTTP add-on interceptor.
+func newHTTPScaledObject(config appConfig) *keda.HTTPScaledObject {
+	target := deploymentScaleTarget(config)
+
+	return &keda.HTTPScaledObject{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: keda.HTTPGroupVersion.String(),
+			Kind:       "HTTPScaledObject",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: autoscalerName(config.Name),
+		},
+		Spec: keda.HTTPScaledObjectSpec{
+			Hosts:        config.KedaHTTPHosts,
+			PathPrefixes: config.KedaHTTPPathPrefixes,
+			ScaleTargetRef: keda.HTTPScaleTarget{
+				APIVersion: target.APIVersion,
+				Kind:       target.Kind,
+				Name:       target.Name,
+				// the operator creates a service with the same name as the SpinApp
+				Service: config.Name,
+				Port:    spinAppPort,
+			},
+			Replicas: &keda.ReplicaStruct{
+				Min: ptr(config.Replicas),
+				Max: ptr(config.MaxReplicas),
+			},
+			TargetPendingRequests: ptr(config.KedaHTTPTargetPendingRequests),
+			ScaledownPeriod:       ptr(config.KedaHTTPScaledownPeriod),
+		},
+	}
+}
+
+// newInterceptorService returns an ExternalName service in the application's namespace that points to the KEDA HTTP
+// add-on interceptor. Requests must be routed through the interceptor, e.g. by pointing an Ingress at this service,
+// for the application to be scaled up from zero.
+func newInterceptorService(config appConfig) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: derivedName(config.Name, "interceptor"),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: fmt.Sprintf("%s.%s.svc.cluster.local", kedaHTTPInterceptorService, config.KedaHTTPInterceptorNamespace),
+			// ExternalName services only alias the DNS name and do not remap ports, so the port must be the port of the
+			// interceptor proxy itself; it documents which port Ingresses have to route to
+			Ports: []corev1.ServicePort{
+				{
+					Name: "http",
+					Port: kedaHTTPInterceptorPort,
+				},
+			},
+		},
+	}
+}
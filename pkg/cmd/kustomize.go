@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomization is the content of a kustomization.yaml file.
+type kustomization struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Resources  []string         `json:"resources,omitempty"`
+	Patches    []kustomizePatch `json:"patches,omitempty"`
+}
+
+type kustomizePatch struct {
+	Path string `json:"path"`
+}
+
+func newKustomization() kustomization {
+	return kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+	}
+}
+
+// parseEnvironmentOverrides parses the --env-flag flags of the form `<env>:<flag>=<value>` into the scaffold flags to
+// set for each environment.
+func parseEnvironmentOverrides(environments, overrides []string) (map[string][]string, error) {
+	args := map[string][]string{}
+	for _, env := range environments {
+		if env == "" || strings.ContainsAny(env, "/:") {
+			return nil, fmt.Errorf("invalid environment name '%s'", env)
+		}
+
+		if _, ok := args[env]; ok {
+			return nil, fmt.Errorf("--env '%s' is specified more than once", env)
+		}
+
+		args[env] = nil
+	}
+
+	for _, value := range overrides {
+		env, flag, found := strings.Cut(value, ":")
+		if !found || env == "" || !strings.Contains(flag, "=") || strings.HasPrefix(flag, "=") {
+			return nil, fmt.Errorf("invalid value '%s' for --env-flag; expected <env>:<flag>=<value>", value)
+		}
+
+		if _, ok := args[env]; !ok {
+			return nil, fmt.Errorf("--env-flag '%s' refers to environment '%s', which is not declared with --env", value, env)
+		}
+
+		args[env] = append(args[env], "--"+flag)
+	}
+
+	return args, nil
+}
+
+// applyFlagOverrides returns a copy of the options with the given flags applied on top. Flags that take key=value
+// pairs, such as --variable, are merged with the base values; all other flags replace them.
+func applyFlagOverrides(base ScaffoldOptions, args []string) (ScaffoldOptions, error) {
+	var opts ScaffoldOptions
+	flags := pflag.NewFlagSet("env-flag", pflag.ContinueOnError)
+	flags.SetOutput(&strings.Builder{})
+	opts.addFlags(flags)
+
+	// registering the flags resets the options to their defaults
+	opts = base
+	if err := flags.Parse(args); err != nil {
+		return ScaffoldOptions{}, err
+	}
+
+	opts.variables = mergeMaps(base.variables, opts.variables)
+	opts.variablesFromSecret = mergeMaps(base.variablesFromSecret, opts.variablesFromSecret)
+	opts.variablesFromConfigMap = mergeMaps(base.variablesFromConfigMap, opts.variablesFromConfigMap)
+	opts.kedaTriggerAuth = mergeMaps(base.kedaTriggerAuth, opts.kedaTriggerAuth)
+	opts.metadata.labels = mergeMaps(base.metadata.labels, opts.metadata.labels)
+	opts.metadata.annotations = mergeMaps(base.metadata.annotations, opts.metadata.annotations)
+	opts.metadata.podLabels = mergeMaps(base.metadata.podLabels, opts.metadata.podLabels)
+	opts.metadata.podAnnotations = mergeMaps(base.metadata.podAnnotations, opts.metadata.podAnnotations)
+	opts.metadata.deploymentAnnotations = mergeMaps(base.metadata.deploymentAnnotations, opts.metadata.deploymentAnnotations)
+	opts.metadata.serviceAnnotations = mergeMaps(base.metadata.serviceAnnotations, opts.metadata.serviceAnnotations)
+
+	return opts, nil
+}
+
+// scaffoldKustomize writes a kustomize base with the scaffold output to `<dir>/base`, and an overlay per environment to
+// `<dir>/overlays/<env>` that patches the base with the environment's flag overrides.
+func scaffoldKustomize(opts ScaffoldOptions) error {
+	envArgs, err := parseEnvironmentOverrides(opts.environments, opts.environmentOverrides)
+	if err != nil {
+		return err
+	}
+
+	baseConfig, err := newAppConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	baseObjects, err := newKustomizeResources(baseConfig)
+	if err != nil {
+		return err
+	}
+
+	base := newKustomization()
+	files := map[string][]byte{}
+	for _, name := range sortedKeys(baseObjects) {
+		base.Resources = append(base.Resources, name)
+		files[filepath.Join("base", name)] = baseObjects[name].content
+	}
+
+	if files[filepath.Join("base", "kustomization.yaml")], err = yaml.Marshal(base); err != nil {
+		return err
+	}
+
+	for _, env := range opts.environments {
+		envOpts, err := applyFlagOverrides(opts, envArgs[env])
+		if err != nil {
+			return fmt.Errorf("invalid --env-flag for environment '%s': %w", env, err)
+		}
+
+		envConfig, err := newAppConfig(envOpts)
+		if err != nil {
+			return fmt.Errorf("invalid configuration for environment '%s': %w", env, err)
+		}
+
+		if envConfig.Name != baseConfig.Name {
+			return fmt.Errorf("the application name of environment '%s' (%s) must match the base (%s)", env, envConfig.Name, baseConfig.Name)
+		}
+
+		envObjects, err := newKustomizeResources(envConfig)
+		if err != nil {
+			return err
+		}
+
+		overlay, overlayFiles, err := newOverlay(baseObjects, envObjects)
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Join("overlays", env)
+		for name, content := range overlayFiles {
+			files[filepath.Join(dir, name)] = content
+		}
+
+		if files[filepath.Join(dir, "kustomization.yaml")], err = yaml.Marshal(overlay); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(opts.kustomize, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// kustomizeResource is a generated object together with its printed manifest.
+type kustomizeResource struct {
+	object  *unstructured.Unstructured
+	content []byte
+}
+
+// newKustomizeResources builds the objects for the configuration, keyed by their file name.
+func newKustomizeResources(config appConfig) (map[string]kustomizeResource, error) {
+	objects, err := buildObjects(config)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := map[string]kustomizeResource{}
+	for _, obj := range objects {
+		u, err := toPrintable(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		var content strings.Builder
+		if err := printObjects(&content, obj); err != nil {
+			return nil, err
+		}
+
+		resources[objectFileName(u)] = kustomizeResource{object: u, content: []byte(content.String())}
+	}
+
+	return resources, nil
+}
+
+// objectFileName returns the name of the file an object is written to when the output is split into several files.
+func objectFileName(u *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s-%s.yaml", strings.ToLower(u.GetKind()), u.GetName())
+}
+
+// newOverlay returns the kustomization and the files of an overlay that turns the base objects into the environment
+// objects: objects that differ are patched, objects that only exist in the environment are added and objects that
+// only exist in the base are deleted.
+func newOverlay(baseObjects, envObjects map[string]kustomizeResource) (kustomization, map[string][]byte, error) {
+	overlay := newKustomization()
+	overlay.Resources = []string{"../../base"}
+	files := map[string][]byte{}
+
+	names := append(sortedKeys(baseObjects), sortedKeys(envObjects)...)
+	slices.Sort(names)
+
+	for _, name := range slices.Compact(names) {
+		base, inBase := baseObjects[name]
+		env, inEnv := envObjects[name]
+
+		switch {
+		case inBase && inEnv:
+			patch, err := newMergePatch(base.object, env.object)
+			if err != nil {
+				return kustomization{}, nil, err
+			}
+
+			if patch == nil {
+				continue
+			}
+
+			patchName := strings.TrimSuffix(name, ".yaml") + "-patch.yaml"
+			overlay.Patches = append(overlay.Patches, kustomizePatch{Path: patchName})
+			files[patchName] = patch
+		case inEnv:
+			overlay.Resources = append(overlay.Resources, name)
+			files[name] = env.content
+		case inBase:
+			patch, err := yaml.Marshal(map[string]any{
+				"apiVersion": base.object.GetAPIVersion(),
+				"kind":       base.object.GetKind(),
+				"metadata":   map[string]any{"name": base.object.GetName()},
+				"$patch":     "delete",
+			})
+			if err != nil {
+				return kustomization{}, nil, err
+			}
+
+			patchName := strings.TrimSuffix(name, ".yaml") + "-delete.yaml"
+			overlay.Patches = append(overlay.Patches, kustomizePatch{Path: patchName})
+			files[patchName] = patch
+		}
+	}
+
+	return overlay, files, nil
+}
+
+// newMergePatch returns a patch that turns the base object into the environment object, or nil if they are equal. The
+// patch identifies its target with the apiVersion, kind and name of the object.
+func newMergePatch(base, env *unstructured.Unstructured) ([]byte, error) {
+	baseJSON, err := json.Marshal(base.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	envJSON, err := json.Marshal(env.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	patchJSON, err := jsonpatch.CreateMergePatch(baseJSON, envJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := map[string]any{}
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return nil, err
+	}
+
+	if len(patch) == 0 {
+		return nil, nil
+	}
+
+	patch["apiVersion"] = env.GetAPIVersion()
+	patch["kind"] = env.GetKind()
+	if err := unstructured.SetNestedField(patch, env.GetName(), "metadata", "name"); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(patch)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKustomize(t *testing.T) {
+	base := ScaffoldOptions{
+		from:                              "ghcr.io/foo/example-app:v0.1.0",
+		executor:                          "containerd-shim-spin",
+		autoscaler:                        "hpa",
+		cpuLimit:                          "100m",
+		memoryLimit:                       "128Mi",
+		replicas:                          2,
+		maxReplicas:                       3,
+		targetCPUUtilizationPercentage:    60,
+		targetMemoryUtilizationPercentage: 60,
+		kedaHTTPTargetPendingRequests:     100,
+		kedaHTTPScaledownPeriod:           300,
+		kedaHTTPInterceptorNamespace:      "keda",
+		imagePullSecrets:                  []string{},
+		variables:                         map[string]string{"greeting": "hello", "farewell": "bye"},
+	}
+
+	opts := base
+	opts.kustomize = t.TempDir()
+	opts.environments = []string{"dev", "prod"}
+	opts.environmentOverrides = []string{
+		"dev:autoscaler=",
+		"dev:replicas=1",
+		"prod:max-replicas=10",
+		"prod:variable=greeting=hi",
+		"prod:runtime-config-file=testdata/runtime-config.toml",
+	}
+
+	require.Nil(t, scaffoldKustomize(opts))
+
+	expectedBase, err := scaffold(base)
+	require.Nil(t, err)
+	requireManifestsEquivalent(t, string(expectedBase), buildKustomization(t, filepath.Join(opts.kustomize, "base")))
+
+	dev := base
+	dev.autoscaler = ""
+	dev.replicas = 1
+	expectedDev, err := scaffold(dev)
+	require.Nil(t, err)
+	requireManifestsEquivalent(t, string(expectedDev), buildKustomization(t, filepath.Join(opts.kustomize, "overlays", "dev")))
+
+	prod := base
+	prod.maxReplicas = 10
+	prod.variables = map[string]string{"greeting": "hi", "farewell": "bye"}
+	prod.configfile = "testdata/runtime-config.toml"
+	expectedProd, err := scaffold(prod)
+	require.Nil(t, err)
+	requireManifestsEquivalent(t, string(expectedProd), buildKustomization(t, filepath.Join(opts.kustomize, "overlays", "prod")))
+
+	// only the fields that differ are patched
+	patch, err := os.ReadFile(filepath.Join(opts.kustomize, "overlays", "prod", "horizontalpodautoscaler-example-app-autoscaler-patch.yaml"))
+	require.Nil(t, err)
+	require.YAMLEq(t, `
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: example-app-autoscaler
+spec:
+  maxReplicas: 10
+`, string(patch))
+}
+
+func TestKustomizeFlagValidation(t *testing.T) {
+	testcases := []struct {
+		name          string
+		environments  []string
+		overrides     []string
+		expectedError string
+	}{
+		{
+			name:          "override for an undeclared environment",
+			environments:  []string{"dev"},
+			overrides:     []string{"prod:replicas=5"},
+			expectedError: "--env-flag 'prod:replicas=5' refers to environment 'prod', which is not declared with --env",
+		},
+		{
+			name:          "malformed override",
+			environments:  []string{"dev"},
+			overrides:     []string{"dev:replicas"},
+			expectedError: "invalid value 'dev:replicas' for --env-flag; expected <env>:<flag>=<value>",
+		},
+		{
+			name:          "unknown flag",
+			environments:  []string{"dev"},
+			overrides:     []string{"dev:replica=5"},
+			expectedError: "invalid --env-flag for environment 'dev': unknown flag: --replica",
+		},
+		{
+			name:          "invalid configuration of an environment",
+			environments:  []string{"dev"},
+			overrides:     []string{"dev:replicas=-1"},
+			expectedError: "invalid configuration for environment 'dev': the minimum replica count (-1) must be greater than 0",
+		},
+		{
+			name:          "environment changes the application name",
+			environments:  []string{"dev"},
+			overrides:     []string{"dev:from=ghcr.io/foo/other-app:v0.1.0"},
+			expectedError: "the application name of environment 'dev' (other-app) must match the base (example-app)",
+		},
+		{
+			name:          "environment declared twice",
+			environments:  []string{"dev", "dev"},
+			expectedError: "--env 'dev' is specified more than once",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := scaffoldKustomize(ScaffoldOptions{
+				from:                 "ghcr.io/foo/example-app:v0.1.0",
+				replicas:             2,
+				kustomize:            t.TempDir(),
+				environments:         tc.environments,
+				environmentOverrides: tc.overrides,
+			})
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+// buildKustomization renders a kustomization generated by scaffold. Patches are applied as JSON merge patches, which
+// is how kustomize applies strategic merge patches to the generated resources.
+func buildKustomization(t *testing.T, dir string) string {
+	content, err := os.ReadFile(filepath.Join(dir, "kustomization.yaml"))
+	require.Nil(t, err)
+
+	var k kustomization
+	require.Nil(t, yaml.Unmarshal(content, &k))
+
+	resources := map[string][]byte{}
+	var order []string
+	for _, resource := range k.Resources {
+		path := filepath.Join(dir, resource)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			for _, doc := range strings.Split(buildKustomization(t, path), "\n---\n") {
+				key := resourceKey(t, []byte(doc))
+				resources[key] = []byte(doc)
+				order = append(order, key)
+			}
+			continue
+		}
+
+		doc, err := os.ReadFile(path)
+		require.Nil(t, err)
+
+		key := resourceKey(t, doc)
+		resources[key] = doc
+		order = append(order, key)
+	}
+
+	for _, patch := range k.Patches {
+		content, err := os.ReadFile(filepath.Join(dir, patch.Path))
+		require.Nil(t, err)
+
+		key := resourceKey(t, content)
+		require.Contains(t, resources, key)
+
+		patchJSON, err := yaml.YAMLToJSON(content)
+		require.Nil(t, err)
+
+		if strings.Contains(string(patchJSON), `"$patch":"delete"`) {
+			delete(resources, key)
+			continue
+		}
+
+		targetJSON, err := yaml.YAMLToJSON(resources[key])
+		require.Nil(t, err)
+
+		patched, err := jsonpatch.MergePatch(targetJSON, patchJSON)
+		require.Nil(t, err)
+
+		resources[key], err = yaml.JSONToYAML(patched)
+		require.Nil(t, err)
+	}
+
+	var docs []string
+	for _, key := range order {
+		if doc, ok := resources[key]; ok {
+			docs = append(docs, strings.TrimSpace(string(doc)))
+		}
+	}
+
+	return strings.Join(docs, "\n---\n")
+}
+
+func resourceKey(t *testing.T, doc []byte) string {
+	var object struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	require.Nil(t, yaml.Unmarshal(doc, &object))
+
+	return object.Kind + "/" + object.Metadata.Name
+}
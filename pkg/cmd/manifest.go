@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const spinManifestFileName = "spin.toml"
+
+// spinManifest is the subset of a Spin application manifest (spin.toml) that is relevant when generating Kubernetes
+// manifests. Both manifest version 1 and version 2 are normalised into this structure.
+type spinManifest struct {
+	Name       string
+	Components []string
+	Variables  map[string]spinManifestVariable
+}
+
+// spinManifestVariable describes an application variable declared in the `[variables]` table.
+type spinManifestVariable struct {
+	Default  *string `toml:"default"`
+	Required bool    `toml:"required"`
+}
+
+// spinManifestVersion is decoded first to determine the layout of the rest of the manifest.
+type spinManifestVersion struct {
+	SpinManifestVersion any `toml:"spin_manifest_version"`
+	SpinVersion         any `toml:"spin_version"`
+}
+
+// spinManifestV1 declares the application name at the top level and components as an array of tables.
+type spinManifestV1 struct {
+	Name      string                          `toml:"name"`
+	Variables map[string]spinManifestVariable `toml:"variables"`
+	Component []struct {
+		ID string `toml:"id"`
+	} `toml:"component"`
+}
+
+// spinManifestV2 uses an `[application]` table and a `[component.<id>]` table per component.
+type spinManifestV2 struct {
+	Application struct {
+		Name string `toml:"name"`
+	} `toml:"application"`
+	Variables map[string]spinManifestVariable `toml:"variables"`
+	Component map[string]any                  `toml:"component"`
+}
+
+// loadSpinManifest reads and parses the Spin manifest at the given path. If path is a directory, the spin.toml file
+// inside it is used.
+func loadSpinManifest(path string) (*spinManifest, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, spinManifestFileName)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := parseSpinManifest(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+func parseSpinManifest(content []byte) (*spinManifest, error) {
+	var header spinManifestVersion
+	if err := toml.Unmarshal(content, &header); err != nil {
+		return nil, err
+	}
+
+	version := header.SpinManifestVersion
+	if version == nil {
+		version = header.SpinVersion
+	}
+
+	manifest := &spinManifest{}
+
+	switch fmt.Sprint(version) {
+	case "1":
+		var raw spinManifestV1
+		if err := toml.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+
+		manifest.Name = raw.Name
+		manifest.Variables = raw.Variables
+		for _, c := range raw.Component {
+			if c.ID == "" {
+				return nil, fmt.Errorf("component is missing an 'id'")
+			}
+
+			manifest.Components = append(manifest.Components, c.ID)
+		}
+	case "2":
+		var raw spinManifestV2
+		if err := toml.Unmarshal(content, &raw); err != nil {
+			return nil, err
+		}
+
+		manifest.Name = raw.Application.Name
+		manifest.Variables = raw.Variables
+		for id := range raw.Component {
+			manifest.Components = append(manifest.Components, id)
+		}
+
+		sort.Strings(manifest.Components)
+	case "<nil>":
+		return nil, fmt.Errorf("missing spin_manifest_version")
+	default:
+		return nil, fmt.Errorf("unsupported spin_manifest_version '%v'", version)
+	}
+
+	return manifest, nil
+}
+
+// parseSpinManifestName returns the application name of a manifest of any version. Unlike parseSpinManifest, it does
+// not validate the rest of the manifest, since every command reads the name from the current directory.
+func parseSpinManifestName(content []byte) (string, error) {
+	manifest := struct {
+		Name        string `toml:"name"`
+		Application struct {
+			Name string `toml:"name"`
+		} `toml:"application"`
+	}{}
+
+	if err := toml.Unmarshal(content, &manifest); err != nil {
+		return "", err
+	}
+
+	if manifest.Application.Name != "" {
+		return manifest.Application.Name, nil
+	}
+
+	return manifest.Name, nil
+}
+
+// defaultVariables returns the default values of the declared variables that are not in provided. An error is
+// returned if a required variable is not provided.
+func (m *spinManifest) defaultVariables(provided map[string]bool) (map[string]string, error) {
+	defaults := map[string]string{}
+
+	for _, name := range sortedKeys(m.Variables) {
+		variable := m.Variables[name]
+		if provided[name] {
+			continue
+		}
+
+		if variable.Default != nil {
+			defaults[name] = *variable.Default
+			continue
+		}
+
+		if variable.Required {
+			return nil, fmt.Errorf("variable '%s' is required by the Spin manifest but no value was provided; set it with --variable %s=<value>", name, name)
+		}
+	}
+
+	return defaults, nil
+}
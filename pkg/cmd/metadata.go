@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// metadataOptions holds the labels and annotations set on the generated resources and on the workloads the operator
+// creates for the SpinApp.
+type metadataOptions struct {
+	annotations           map[string]string
+	deploymentAnnotations map[string]string
+	labels                map[string]string
+	podAnnotations        map[string]string
+	podLabels             map[string]string
+	serviceAnnotations    map[string]string
+}
+
+func (o *metadataOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringToStringVar(&o.labels, "label", nil, "Label (key=value) set on every generated resource. This can be specified multiple times")
+	flags.StringToStringVar(&o.annotations, "annotation", nil, "Annotation (key=value) set on every generated resource. This can be specified multiple times")
+	flags.StringToStringVar(&o.podLabels, "pod-label", nil, "Label (key=value) set on the pods of the application. This can be specified multiple times")
+	flags.StringToStringVar(&o.podAnnotations, "pod-annotation", nil, "Annotation (key=value) set on the pods of the application. This can be specified multiple times")
+	flags.StringToStringVar(&o.deploymentAnnotations, "deployment-annotation", nil, "Annotation (key=value) set on the deployment of the application. This can be specified multiple times")
+	flags.StringToStringVar(&o.serviceAnnotations, "service-annotation", nil, "Annotation (key=value) set on the service of the application. This can be specified multiple times")
+}
+
+func (o metadataOptions) validate() error {
+	for _, labels := range []struct {
+		flag   string
+		values map[string]string
+	}{
+		{"label", o.labels},
+		{"pod-label", o.podLabels},
+	} {
+		for _, key := range sortedKeys(labels.values) {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return fmt.Errorf("invalid --%s key '%s': %s", labels.flag, key, strings.Join(errs, "; "))
+			}
+
+			if errs := validation.IsValidLabelValue(labels.values[key]); len(errs) > 0 {
+				return fmt.Errorf("invalid --%s value '%s' for key '%s': %s", labels.flag, labels.values[key], key, strings.Join(errs, "; "))
+			}
+		}
+	}
+
+	for _, annotations := range []struct {
+		flag   string
+		values map[string]string
+	}{
+		{"annotation", o.annotations},
+		{"pod-annotation", o.podAnnotations},
+		{"deployment-annotation", o.deploymentAnnotations},
+		{"service-annotation", o.serviceAnnotations},
+	} {
+		for _, key := range sortedKeys(annotations.values) {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return fmt.Errorf("invalid --%s key '%s': %s", annotations.flag, key, strings.Join(errs, "; "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyToSpinApp sets the pod, deployment and service metadata on the SpinApp spec.
+func (o metadataOptions) applyToSpinApp(app *spinv1alpha1.SpinApp) {
+	app.Spec.PodLabels = o.podLabels
+	app.Spec.PodAnnotations = o.podAnnotations
+	app.Spec.DeploymentAnnotations = o.deploymentAnnotations
+	app.Spec.ServiceAnnotations = o.serviceAnnotations
+}
+
+// applyToObjects adds the top-level labels and annotations to the metadata of every object.
+func (o metadataOptions) applyToObjects(objects ...runtime.Object) error {
+	if len(o.labels) == 0 && len(o.annotations) == 0 {
+		return nil
+	}
+
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+
+		accessor.SetLabels(mergeMaps(accessor.GetLabels(), o.labels))
+		accessor.SetAnnotations(mergeMaps(accessor.GetAnnotations(), o.annotations))
+	}
+
+	return nil
+}
+
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return base
+	}
+
+	merged := make(map[string]string, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overrides {
+		merged[key] = value
+	}
+
+	return merged
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dockerparser "github.com/novln/docker-parser"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// nameHashLength is the length of the hash suffix that keeps truncated names unique.
+const nameHashLength = 8
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// resolveAppName returns the application name set with --name, or the name derived from the image reference.
+func resolveAppName(name, imageRef string) (string, error) {
+	if name != "" {
+		if err := validateName("--name", name); err != nil {
+			return "", err
+		}
+
+		return name, nil
+	}
+
+	name, err := getNameFromImageReference(imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("cannot derive a name from the image reference '%s'; set one with --name", imageRef)
+	}
+
+	if err := validateName("name derived from the image reference", name); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// getNameFromImageReference returns the last path segment of the image repository, sanitized to a DNS-1123 label.
+func getNameFromImageReference(imageRef string) (string, error) {
+	// the parser rejects uppercase repositories, which the generated resources refer to in lowercase
+	ref, err := dockerparser.Parse(lowercaseRepository(imageRef))
+	if err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(ref.ShortName(), "/")
+
+	return sanitizeName(parts[len(parts)-1]), nil
+}
+
+// lowercaseRepository lowercases the image reference up to its tag or digest, which may contain uppercase letters.
+func lowercaseRepository(imageRef string) string {
+	repository, suffix := imageRef, ""
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, suffix = repository[:i], repository[i:]
+	}
+
+	// a colon before the last slash separates the registry port, not the tag
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, suffix = repository[:i], repository[i:]+suffix
+	}
+
+	return strings.ToLower(repository) + suffix
+}
+
+// sanitizeName converts the value to a DNS-1123 label: it is lowercased, runs of invalid characters are replaced with
+// a single '-', and names that are too long are truncated and suffixed with a hash of the value so that they stay
+// stable and unique.
+func sanitizeName(value string) string {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(value), "-")
+	name = strings.Trim(name, "-")
+
+	return truncateName(name, value, validation.DNS1123LabelMaxLength)
+}
+
+// derivedName returns the name of an object generated for the application, e.g. <name>-autoscaler. When the result
+// does not fit in a DNS-1123 label, the application name is truncated and suffixed with a hash the same way
+// sanitizeName does, so that the name stays stable and unique.
+func derivedName(appName, suffix string) string {
+	return truncateName(appName, appName, validation.DNS1123LabelMaxLength-len(suffix)-1) + "-" + suffix
+}
+
+// truncateName returns the name unchanged when it fits in maxLength, and otherwise truncates it and appends a hash of
+// value.
+func truncateName(name, value string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	prefix := strings.TrimRight(name[:maxLength-nameHashLength-1], "-")
+
+	return prefix + "-" + hex.EncodeToString(sum[:])[:nameHashLength]
+}
+
+func validateName(description, name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid %s '%s': %s", description, name, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// setNamespace sets the namespace on the metadata of every object.
+func setNamespace(namespace string, objects ...runtime.Object) error {
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+
+		accessor.SetNamespace(namespace)
+	}
+
+	return nil
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/keda"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const runtimeConfigSecretKey = "runtime-config.toml"
+
+// buildObjects returns the Kubernetes objects described by the given application config, in the order they should be
+// printed or applied.
+func buildObjects(config appConfig) ([]runtime.Object, error) {
+	spinapp, err := newSpinApp(config)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []runtime.Object{spinapp}
+
+	if config.RuntimeConfig != nil {
+		objects = append(objects, newRuntimeConfigSecret(config))
+	}
+
+	for _, pvc := range config.PersistentVolumeClaims {
+		objects = append(objects, pvc)
+	}
+
+	switch config.Autoscaler {
+	case "hpa":
+		objects = append(objects, newHorizontalPodAutoscaler(config))
+	case "keda":
+		if len(config.KedaTriggerAuth) > 0 {
+			auth, err := newTriggerAuthentication(config)
+			if err != nil {
+				return nil, err
+			}
+
+			triggerAuthentication, err := toUnstructured(auth)
+			if err != nil {
+				return nil, err
+			}
+
+			objects = append(objects, triggerAuthentication)
+		}
+
+		scaledObject, err := toUnstructured(newScaledObject(config))
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, scaledObject)
+	case "keda-http":
+		httpScaledObject, err := toUnstructured(newHTTPScaledObject(config))
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, httpScaledObject, newInterceptorService(config))
+	}
+
+	if err := config.Metadata.applyToObjects(objects...); err != nil {
+		return nil, err
+	}
+
+	if config.Namespace != "" {
+		if err := setNamespace(config.Namespace, objects...); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+func newSpinApp(config appConfig) (*spinv1alpha1.SpinApp, error) {
+	spinapp := &spinv1alpha1.SpinApp{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: spinv1alpha1.GroupVersion.String(),
+			Kind:       "SpinApp",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.Name,
+		},
+		Spec: spinv1alpha1.SpinAppSpec{
+			Image:        config.Image,
+			Executor:     config.Executor,
+			Checks:       config.Checks,
+			Variables:    config.Variables,
+			Components:   config.Components,
+			Volumes:      config.Volumes,
+			VolumeMounts: config.VolumeMounts,
+		},
+	}
+
+	if config.ImageTag != "" {
+		spinapp.Annotations = map[string]string{imageTagAnnotation: config.ImageTag}
+	}
+
+	config.Metadata.applyToSpinApp(spinapp)
+
+	if config.Autoscaler != "" {
+		spinapp.Spec.EnableAutoscaling = true
+	} else {
+		spinapp.Spec.Replicas = config.Replicas
+	}
+
+	var err error
+	spinapp.Spec.Resources.Limits, err = newResourceList(config.CPULimit, config.MemoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource limits: %w", err)
+	}
+
+	// requests that are not set default to their respective limit
+	spinapp.Spec.Resources.Requests, err = newResourceList(cmp.Or(config.CPURequest, config.CPULimit), cmp.Or(config.MemoryRequest, config.MemoryLimit))
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource requests: %w", err)
+	}
+
+	for _, secret := range config.ImagePullSecrets {
+		spinapp.Spec.ImagePullSecrets = append(spinapp.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+	}
+
+	if config.RuntimeConfig != nil {
+		spinapp.Spec.RuntimeConfig.LoadFromSecret = runtimeConfigSecretName(config.Name)
+	}
+
+	spinapp.Spec.RuntimeConfig.KeyValueStores = config.KeyValueStores
+	spinapp.Spec.RuntimeConfig.SqliteDatabases = config.SqliteDatabases
+	spinapp.Spec.RuntimeConfig.LLMCompute = config.LLMCompute
+
+	return spinapp, nil
+}
+
+func newHealthProbe(opts ScaffoldOptions, path string) *spinv1alpha1.HealthProbe {
+	return &spinv1alpha1.HealthProbe{
+		HTTPGet: &spinv1alpha1.HTTPHealthProbe{
+			Path: path,
+			// httpHeaders is optional, but has no omitempty: an empty slice renders as [] instead of null, which keeps
+			// null values out of the generated manifests and the diff against the live object
+			HTTPHeaders: []spinv1alpha1.HTTPHealthProbeHeader{},
+		},
+		InitialDelaySeconds: opts.probeInitialDelaySeconds,
+		PeriodSeconds:       opts.probePeriodSeconds,
+		TimeoutSeconds:      opts.probeTimeoutSeconds,
+		FailureThreshold:    opts.probeFailureThreshold,
+	}
+}
+
+func newResourceList(cpu, memory string) (corev1.ResourceList, error) {
+	resources := corev1.ResourceList{}
+	for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s '%s': %w", name, value, err)
+		}
+
+		resources[name] = quantity
+	}
+
+	if len(resources) == 0 {
+		return nil, nil
+	}
+
+	return resources, nil
+}
+
+func runtimeConfigSecretName(appName string) string {
+	return derivedName(appName, "runtime-config")
+}
+
+func autoscalerName(appName string) string {
+	return derivedName(appName, "autoscaler")
+}
+
+func newRuntimeConfigSecret(config appConfig) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: runtimeConfigSecretName(config.Name),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			runtimeConfigSecretKey: config.RuntimeConfig,
+		},
+	}
+}
+
+// deploymentScaleTarget returns a reference to the Deployment the operator creates for the SpinApp.
+func deploymentScaleTarget(config appConfig) autoscalingv2.CrossVersionObjectReference {
+	return autoscalingv2.CrossVersionObjectReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       config.Name,
+	}
+}
+
+func newHorizontalPodAutoscaler(config appConfig) *autoscalingv2.HorizontalPodAutoscaler {
+	var metrics []autoscalingv2.MetricSpec
+	if !config.DisableCPUAutoscaling {
+		metrics = append(metrics, newResourceMetric(corev1.ResourceCPU, config.TargetCPUUtilizationPercentage))
+	}
+
+	if !config.DisableMemoryAutoscaling {
+		metrics = append(metrics, newResourceMetric(corev1.ResourceMemory, config.TargetMemoryUtilizationPercentage))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: autoscalingv2.SchemeGroupVersion.String(),
+			Kind:       "HorizontalPodAutoscaler",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: autoscalerName(config.Name),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: deploymentScaleTarget(config),
+			MinReplicas:    ptr(config.Replicas),
+			MaxReplicas:    config.MaxReplicas,
+			Metrics:        append(metrics, config.HPAMetrics...),
+			Behavior:       config.HPABehavior,
+		},
+	}
+}
+
+func newResourceMetric(name corev1.ResourceName, averageUtilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: ptr(averageUtilization),
+			},
+		},
+	}
+}
+
+func newScaledObject(config appConfig) *keda.ScaledObject {
+	target := deploymentScaleTarget(config)
+
+	var triggers []keda.ScaleTriggers
+	if !config.DisableCPUAutoscaling {
+		triggers = append(triggers, newResourceTrigger(corev1.ResourceCPU, config.TargetCPUUtilizationPercentage))
+	}
+
+	if !config.DisableMemoryAutoscaling {
+		triggers = append(triggers, newResourceTrigger(corev1.ResourceMemory, config.TargetMemoryUtilizationPercentage))
+	}
+
+	for _, trigger := range config.KedaTriggers {
+		if len(config.KedaTriggerAuth) > 0 {
+			trigger.AuthenticationRef = &keda.AuthenticationRef{Name: triggerAuthenticationName(config.Name)}
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	return &keda.ScaledObject{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: keda.GroupVersion.String(),
+			Kind:       "ScaledObject",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: autoscalerName(config.Name),
+		},
+		Spec: keda.ScaledObjectSpec{
+			ScaleTargetRef: &keda.ScaleTarget{
+				APIVersion: target.APIVersion,
+				Kind:       target.Kind,
+				Name:       target.Name,
+			},
+			MinReplicaCount: ptr(config.Replicas),
+			MaxReplicaCount: ptr(config.MaxReplicas),
+			Triggers:        triggers,
+		},
+	}
+}
+
+func newResourceTrigger(name corev1.ResourceName, value int32) keda.ScaleTriggers {
+	return keda.ScaleTriggers{
+		Type:       string(name),
+		MetricType: string(autoscalingv2.UtilizationMetricType),
+		Metadata: map[string]string{
+			"value": fmt.Sprint(value),
+		},
+	}
+}
+
+// toUnstructured converts objects whose Go types are not registered with a scheme, such as the KEDA resources, into
+// unstructured objects.
+func toUnstructured(obj any) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/gosuri/uitable"
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
 )
 
 func printApps(w io.Writer, apps ...spinv1alpha1.SpinApp) {
@@ -19,3 +22,69 @@ func printApps(w io.Writer, apps ...spinv1alpha1.SpinApp) {
 
 	fmt.Fprintln(w, table)
 }
+
+// emptySpinAppFields lists the SpinApp spec fields that are serialized even when they are not set, because they are
+// not pointers.
+var emptySpinAppFields = []string{"checks", "resources", "runtimeConfig"}
+
+// printObjects writes the given objects to w as a multi-document YAML manifest.
+func printObjects(w io.Writer, objects ...runtime.Object) error {
+	printer := printers.YAMLPrinter{}
+
+	for _, obj := range objects {
+		u, err := toPrintable(obj)
+		if err != nil {
+			return err
+		}
+
+		if err := printer.PrintObj(u, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toPrintable converts the object to its unstructured form, omitting fields that are only meaningful on objects read
+// back from the cluster, such as the status and the creation timestamp.
+func toPrintable(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+
+	if u.GetKind() == "SpinApp" {
+		for _, field := range emptySpinAppFields {
+			if value, found, _ := unstructured.NestedMap(u.Object, "spec", field); found && len(value) == 0 {
+				unstructured.RemoveNestedField(u.Object, "spec", field)
+			}
+		}
+	}
+
+	if u.GetKind() == "Service" {
+		removeUnsetTargetPorts(u)
+	}
+
+	return u, nil
+}
+
+// removeUnsetTargetPorts removes the targetPort of service ports that do not set it. The field is not a pointer, so it
+// is converted to 0 rather than left out.
+func removeUnsetTargetPorts(u *unstructured.Unstructured) {
+	ports, found, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+	if !found {
+		return
+	}
+
+	for _, port := range ports {
+		if fields, ok := port.(map[string]any); ok && fields["targetPort"] == int64(0) {
+			delete(fields, "targetPort")
+		}
+	}
+
+	_ = unstructured.SetNestedSlice(u.Object, ports, "spec", "ports")
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+const projectConfigFileName = ".spinkube.yaml"
+
+// projectSettings are the flag defaults of a project. They are set at the top level of the project config, and can be
+// overridden by a profile.
+type projectSettings struct {
+	Image            string             `json:"image,omitempty" toml:"image"`
+	Executor         string             `json:"executor,omitempty" toml:"executor"`
+	Replicas         *int32             `json:"replicas,omitempty" toml:"replicas"`
+	Resources        *projectResources  `json:"resources,omitempty" toml:"resources"`
+	Autoscaler       *projectAutoscaler `json:"autoscaler,omitempty" toml:"autoscaler"`
+	Variables        map[string]string  `json:"variables,omitempty" toml:"variables"`
+	ImagePullSecrets []string           `json:"imagePullSecrets,omitempty" toml:"imagePullSecrets"`
+	Namespace        string             `json:"namespace,omitempty" toml:"namespace"`
+	Context          string             `json:"context,omitempty" toml:"context"`
+}
+
+type projectResources struct {
+	CPULimit      string `json:"cpuLimit,omitempty" toml:"cpuLimit"`
+	MemoryLimit   string `json:"memoryLimit,omitempty" toml:"memoryLimit"`
+	CPURequest    string `json:"cpuRequest,omitempty" toml:"cpuRequest"`
+	MemoryRequest string `json:"memoryRequest,omitempty" toml:"memoryRequest"`
+}
+
+type projectAutoscaler struct {
+	Type                    string `json:"type,omitempty" toml:"type"`
+	MaxReplicas             *int32 `json:"maxReplicas,omitempty" toml:"maxReplicas"`
+	TargetCPUUtilization    *int32 `json:"targetCPUUtilization,omitempty" toml:"targetCPUUtilization"`
+	TargetMemoryUtilization *int32 `json:"targetMemoryUtilization,omitempty" toml:"targetMemoryUtilization"`
+}
+
+// projectConfig is the content of .spinkube.yaml, or of the `[tool.spinkube]` table of spin.toml.
+type projectConfig struct {
+	projectSettings `toml:",inline"`
+	Profiles        map[string]projectSettings `json:"profiles,omitempty" toml:"profiles"`
+
+	// path is the file the config was loaded from.
+	path string
+}
+
+// loadProjectConfig loads the project config from .spinkube.yaml in the current directory or, if it does not exist,
+// from the `[tool.spinkube]` table of spin.toml. It returns nil if the project has no config.
+func loadProjectConfig() (*projectConfig, error) {
+	if strings.ToLower(os.Getenv("SPIN_KUBE_DISABLE_DIR_CONTEXT")) == "true" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(projectConfigFileName)
+	if err == nil {
+		config := &projectConfig{path: projectConfigFileName}
+		if err := yaml.UnmarshalStrict(content, config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", projectConfigFileName, err)
+		}
+
+		return config, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	content, err = os.ReadFile(spinManifestFileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Tool struct {
+			SpinKube *projectConfig `toml:"spinkube"`
+		} `toml:"tool"`
+	}
+	if err := toml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", spinManifestFileName, err)
+	}
+
+	if manifest.Tool.SpinKube == nil {
+		return nil, nil
+	}
+
+	manifest.Tool.SpinKube.path = spinManifestFileName + " [tool.spinkube]"
+	return manifest.Tool.SpinKube, nil
+}
+
+// resolve returns the settings of the profile applied on top of the top-level settings. An empty profile returns the
+// top-level settings.
+func (c *projectConfig) resolve(profile string) (projectSettings, error) {
+	if profile == "" {
+		return c.projectSettings, nil
+	}
+
+	overrides, ok := c.Profiles[profile]
+	if !ok {
+		return projectSettings{}, fmt.Errorf("profile '%s' is not defined in %s", profile, c.path)
+	}
+
+	return mergeProjectSettings(c.projectSettings, overrides), nil
+}
+
+// mergeProjectSettings returns the base settings with every value that is set in overrides replaced. Variables are
+// merged by name.
+func mergeProjectSettings(base, overrides projectSettings) projectSettings {
+	merged := base
+	merged.Image = cmp.Or(overrides.Image, base.Image)
+	merged.Executor = cmp.Or(overrides.Executor, base.Executor)
+	merged.Namespace = cmp.Or(overrides.Namespace, base.Namespace)
+	merged.Context = cmp.Or(overrides.Context, base.Context)
+	merged.Replicas = cmp.Or(overrides.Replicas, base.Replicas)
+	merged.Variables = mergeMaps(base.Variables, overrides.Variables)
+
+	if overrides.ImagePullSecrets != nil {
+		merged.ImagePullSecrets = overrides.ImagePullSecrets
+	}
+
+	if overrides.Resources != nil {
+		resources := projectResources{}
+		if base.Resources != nil {
+			resources = *base.Resources
+		}
+
+		resources.CPULimit = cmp.Or(overrides.Resources.CPULimit, resources.CPULimit)
+		resources.MemoryLimit = cmp.Or(overrides.Resources.MemoryLimit, resources.MemoryLimit)
+		resources.CPURequest = cmp.Or(overrides.Resources.CPURequest, resources.CPURequest)
+		resources.MemoryRequest = cmp.Or(overrides.Resources.MemoryRequest, resources.MemoryRequest)
+		merged.Resources = &resources
+	}
+
+	if overrides.Autoscaler != nil {
+		autoscaler := projectAutoscaler{}
+		if base.Autoscaler != nil {
+			autoscaler = *base.Autoscaler
+		}
+
+		autoscaler.Type = cmp.Or(overrides.Autoscaler.Type, autoscaler.Type)
+		autoscaler.MaxReplicas = cmp.Or(overrides.Autoscaler.MaxReplicas, autoscaler.MaxReplicas)
+		autoscaler.TargetCPUUtilization = cmp.Or(overrides.Autoscaler.TargetCPUUtilization, autoscaler.TargetCPUUtilization)
+		autoscaler.TargetMemoryUtilization = cmp.Or(overrides.Autoscaler.TargetMemoryUtilization, autoscaler.TargetMemoryUtilization)
+		merged.Autoscaler = &autoscaler
+	}
+
+	return merged
+}
+
+// projectFlag is a flag set from the project config.
+type projectFlag struct {
+	name   string
+	values []string
+}
+
+// flags returns the flags the settings translate to.
+func (s projectSettings) flags() []projectFlag {
+	var flags []projectFlag
+	add := func(name string, values ...string) {
+		if len(values) > 0 && values[0] != "" {
+			flags = append(flags, projectFlag{name: name, values: values})
+		}
+	}
+	addInt := func(name string, value *int32) {
+		if value != nil {
+			add(name, strconv.Itoa(int(*value)))
+		}
+	}
+
+	add("from", s.Image)
+	add("executor", s.Executor)
+	addInt("replicas", s.Replicas)
+	add("image-pull-secret", s.ImagePullSecrets...)
+	add("namespace", s.Namespace)
+	add("context", s.Context)
+
+	if s.Resources != nil {
+		add("cpu-limit", s.Resources.CPULimit)
+		add("memory-limit", s.Resources.MemoryLimit)
+		add("cpu-request", s.Resources.CPURequest)
+		add("memory-request", s.Resources.MemoryRequest)
+	}
+
+	if s.Autoscaler != nil {
+		add("autoscaler", s.Autoscaler.Type)
+		addInt("max-replicas", s.Autoscaler.MaxReplicas)
+		addInt("autoscaler-target-cpu-utilization", s.Autoscaler.TargetCPUUtilization)
+		addInt("autoscaler-target-memory-utilization", s.Autoscaler.TargetMemoryUtilization)
+	}
+
+	var variables []string
+	for _, name := range sortedKeys(s.Variables) {
+		variables = append(variables, name+"="+s.Variables[name])
+	}
+	add("variable", variables...)
+
+	return flags
+}
+
+// applyProjectSettings sets the flags of a command that were not set explicitly to the values of the settings. Flags
+// the command does not have are ignored. Variables set explicitly are merged with the ones of the settings.
+func applyProjectSettings(flags *pflag.FlagSet, settings projectSettings) error {
+	for _, flag := range settings.flags() {
+		f := flags.Lookup(flag.name)
+		if f == nil {
+			continue
+		}
+
+		values := flag.values
+		if f.Changed {
+			if f.Value.Type() != "stringToString" {
+				continue
+			}
+
+			explicit, err := flags.GetStringToString(flag.name)
+			if err != nil {
+				return err
+			}
+
+			values = slices.DeleteFunc(slices.Clone(values), func(value string) bool {
+				name, _, _ := strings.Cut(value, "=")
+				_, ok := explicit[name]
+				return ok
+			})
+		}
+
+		for _, value := range values {
+			// values that contain commas are quoted, as they are parsed as CSV
+			if f.Value.Type() == "stringToString" && strings.ContainsAny(value, `,"`) {
+				value = `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+			}
+
+			if err := flags.Set(flag.name, value); err != nil {
+				return fmt.Errorf("invalid value '%s' for %s in the project config: %w", value, flag.name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+const testProjectConfig = `
+image: ghcr.io/foo/example-app:v0.1.0
+executor: containerd-shim-spin
+replicas: 2
+resources:
+  cpuLimit: 100m
+  memoryLimit: 128Mi
+variables:
+  greeting: hello
+  farewell: bye
+imagePullSecrets:
+- registry-credentials
+namespace: dev
+profiles:
+  prod:
+    namespace: prod
+    replicas: 3
+    resources:
+      memoryLimit: 256Mi
+    autoscaler:
+      type: hpa
+      maxReplicas: 10
+      targetCPUUtilization: 60
+      targetMemoryUtilization: 60
+    variables:
+      greeting: hi
+`
+
+// chdir changes the working directory to dir for the duration of the test.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir(dir))
+	t.Cleanup(func() { require.Nil(t, os.Chdir(wd)) })
+}
+
+func TestProjectConfig(t *testing.T) {
+	testcases := []struct {
+		name     string
+		profile  string
+		args     []string
+		expected func(opts *ScaffoldOptions)
+	}{
+		{
+			name: "file defaults",
+			expected: func(opts *ScaffoldOptions) {
+				opts.namespace = "dev"
+				opts.replicas = 2
+				opts.memoryLimit = "128Mi"
+				opts.variables = map[string]string{"greeting": "hello", "farewell": "bye"}
+			},
+		},
+		{
+			name:    "profile over file defaults",
+			profile: "prod",
+			expected: func(opts *ScaffoldOptions) {
+				opts.namespace = "prod"
+				opts.replicas = 3
+				opts.memoryLimit = "256Mi"
+				opts.autoscaler = "hpa"
+				opts.maxReplicas = 10
+				opts.variables = map[string]string{"greeting": "hi", "farewell": "bye"}
+			},
+		},
+		{
+			name:    "flags over profile",
+			profile: "prod",
+			args:    []string{"--replicas=5", "--namespace=staging", "--variable", "greeting=hey,there", "--image-pull-secret=other"},
+			expected: func(opts *ScaffoldOptions) {
+				opts.namespace = "staging"
+				opts.replicas = 5
+				opts.memoryLimit = "256Mi"
+				opts.autoscaler = "hpa"
+				opts.maxReplicas = 10
+				opts.variables = map[string]string{"greeting": "hey,there", "farewell": "bye"}
+				opts.imagePullSecrets = []string{"other"}
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			chdir(t, t.TempDir())
+			require.Nil(t, os.WriteFile(projectConfigFileName, []byte(testProjectConfig), 0644))
+
+			config, err := loadProjectConfig()
+			require.Nil(t, err)
+
+			settings, err := config.resolve(tc.profile)
+			require.Nil(t, err)
+
+			var opts ScaffoldOptions
+			flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+			opts.addFlags(flags)
+			flags.StringVarP(&opts.namespace, "namespace", "n", "", "")
+			require.Nil(t, flags.Parse(tc.args))
+			require.Nil(t, applyProjectSettings(flags, settings))
+
+			expected := opts
+			expected.from = "ghcr.io/foo/example-app:v0.1.0"
+			expected.executor = "containerd-shim-spin"
+			expected.cpuLimit = "100m"
+			expected.imagePullSecrets = []string{"registry-credentials"}
+			tc.expected(&expected)
+
+			require.Equal(t, expected, opts)
+		})
+	}
+}
+
+func TestProjectConfigFromSpinManifest(t *testing.T) {
+	chdir(t, t.TempDir())
+	require.Nil(t, os.WriteFile(spinManifestFileName, []byte(`
+spin_manifest_version = 2
+
+[application]
+name = "example-app"
+
+[tool.spinkube]
+image = "ghcr.io/foo/example-app:v0.1.0"
+replicas = 2
+
+[tool.spinkube.profiles.prod]
+replicas = 4
+`), 0644))
+
+	config, err := loadProjectConfig()
+	require.Nil(t, err)
+
+	var output strings.Builder
+	require.Nil(t, printProjectConfig(&output, config, "prod"))
+	require.Equal(t, `# source: spin.toml [tool.spinkube]
+# profile: prod
+image: ghcr.io/foo/example-app:v0.1.0
+replicas: 4
+`, output.String())
+
+	_, err = config.resolve("staging")
+	require.EqualError(t, err, "profile 'staging' is not defined in spin.toml [tool.spinkube]")
+}
+
+func TestProjectConfigUnknownField(t *testing.T) {
+	chdir(t, t.TempDir())
+	require.Nil(t, os.WriteFile(projectConfigFileName, []byte("replica: 2\n"), 0644))
+
+	_, err := loadProjectConfig()
+	require.ErrorContains(t, err, `failed to parse .spinkube.yaml: error unmarshaling JSON: while decoding JSON: json: unknown field "replica"`)
+}
@@ -1,10 +1,10 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
-	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spinkube/spin-plugin-kube/pkg/kube"
@@ -17,6 +17,7 @@ var (
 	appNameFromCurrentDirContext = ""
 	configFlags                  = genericclioptions.NewConfigFlags(true)
 	namespace                    string
+	profile                      string
 	kubeImpl                     *kube.Impl
 	isExperimentalFlagNotSet     = os.Getenv("SPIN_EXPERIMENTAL") == ""
 )
@@ -29,7 +30,11 @@ func newRootCmd() *cobra.Command {
 		Use:     "kube",
 		Short:   "Manage applications running on Kubernetes",
 		Version: Version,
-		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if err := initProjectConfig(cmd.Flags()); err != nil {
+				return err
+			}
+
 			namespace = getNamespace(configFlags)
 			k8sclient, err := getRuntimeClient()
 			if err != nil {
@@ -71,15 +76,14 @@ func newRootCmd() *cobra.Command {
 		}
 	})
 	root.Flags().AddFlagSet(flagSet)
+	root.PersistentFlags().StringVar(&profile, "profile", "", "the profile of the project config to use")
 	return root
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
-	}
+func Execute() error {
+	return rootCmd.Execute()
 }
 
 // getNamespace takes a set of kubectl flag values and returns the namespace we should be operating in
@@ -92,27 +96,52 @@ func getNamespace(flags *genericclioptions.ConfigFlags) string {
 	return namespace
 }
 
+// initProjectConfig applies the project config, with the selected profile, to the flags that were not set explicitly.
+func initProjectConfig(flags *pflag.FlagSet) error {
+	config, err := loadProjectConfig()
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		if profile != "" {
+			return fmt.Errorf("--profile requires a %s file or a [tool.spinkube] table in %s", projectConfigFileName, spinManifestFileName)
+		}
+
+		return nil
+	}
+
+	settings, err := config.resolve(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := applyProjectSettings(flags, settings); err != nil {
+		return err
+	}
+
+	// commands without the kubectl flags still talk to the cluster of the configured context
+	if flags.Lookup("context") == nil && *configFlags.Context == "" {
+		*configFlags.Context = settings.Context
+	}
+
+	return nil
+}
+
 func initAppNameFromCurrentDirContext() (string, error) {
 	if strings.ToLower(os.Getenv("SPIN_KUBE_DISABLE_DIR_CONTEXT")) == "true" {
 		return "", nil
 	}
 
-	content, err := os.ReadFile("spin.toml")
+	content, err := os.ReadFile(spinManifestFileName)
 	// running from a non spin-app dir
 	if os.IsNotExist(err) {
 		return "", nil
 	}
 
-	manifest := struct {
-		Application struct {
-			Name string `toml:"name"`
-		} `toml:"application"`
-	}{}
-
-	err = toml.Unmarshal(content, &manifest)
 	if err != nil {
 		return "", err
 	}
 
-	return manifest.Application.Name, nil
+	return parseSpinManifestName(content)
 }
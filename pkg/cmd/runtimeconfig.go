@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The store types Spin understands in each section of the runtime config file, mapped to the keys each type requires.
+var (
+	keyValueStoreTypes = map[string][]string{
+		"spin":         nil,
+		"redis":        {"url"},
+		"azure_cosmos": {"account", "database", "container"},
+		"aws_dynamo":   {"region", "table"},
+	}
+
+	sqliteDatabaseTypes = map[string][]string{
+		"spin":   nil,
+		"libsql": {"url", "token"},
+	}
+
+	llmComputeTypes = map[string][]string{
+		"spin":        nil,
+		"remote_http": {"url", "auth_token"},
+	}
+
+	configProviderTypes = map[string][]string{
+		"vault":           {"url", "token", "mount"},
+		"azure_key_vault": {"vault_url"},
+	}
+)
+
+// validateRuntimeConfig checks that the content is a runtime config file Spin can load. It reports syntax errors with
+// their position, as well as unknown store types and missing required keys in the sections Spin understands.
+func validateRuntimeConfig(content []byte) error {
+	config := map[string]any{}
+	if err := toml.Unmarshal(content, &config); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			row, column := decodeErr.Position()
+			return fmt.Errorf("line %d, column %d: %s", row, column, decodeErr.Error())
+		}
+
+		return err
+	}
+
+	var problems []string
+
+	for _, section := range []struct {
+		name  string
+		types map[string][]string
+	}{
+		{name: "key_value_store", types: keyValueStoreTypes},
+		{name: "sqlite_database", types: sqliteDatabaseTypes},
+	} {
+		value, ok := config[section.name]
+		if !ok {
+			continue
+		}
+
+		stores, ok := value.(map[string]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected a table of [%s.<label>] tables", section.name, section.name))
+			continue
+		}
+
+		for _, label := range sortedKeys(stores) {
+			problems = append(problems, validateRuntimeConfigTable(section.name+"."+label, stores[label], section.types)...)
+		}
+	}
+
+	if value, ok := config["llm_compute"]; ok {
+		problems = append(problems, validateRuntimeConfigTable("llm_compute", value, llmComputeTypes)...)
+	}
+
+	for _, name := range []string{"config_provider", "variables_provider"} {
+		value, ok := config[name]
+		if !ok {
+			continue
+		}
+
+		providers, ok := value.([]any)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected an array of [[%s]] tables", name, name))
+			continue
+		}
+
+		for i, provider := range providers {
+			problems = append(problems, validateRuntimeConfigTable(fmt.Sprintf("%s[%d]", name, i), provider, configProviderTypes)...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// validateRuntimeConfigTable checks that the table has a known `type` and all the keys that type requires.
+func validateRuntimeConfigTable(path string, value any, types map[string][]string) []string {
+	table, ok := value.(map[string]any)
+	if !ok {
+		return []string{fmt.Sprintf("%s: expected a table", path)}
+	}
+
+	storeType, ok := table["type"].(string)
+	if !ok {
+		return []string{fmt.Sprintf("%s: missing required key 'type'", path)}
+	}
+
+	required, ok := types[storeType]
+	if !ok {
+		known := sortedKeys(types)
+		return []string{fmt.Sprintf("%s: unknown type '%s'; expected one of %s", path, storeType, strings.Join(known, ", "))}
+	}
+
+	var problems []string
+	for _, key := range required {
+		if value, ok := table[key].(string); !ok || value == "" {
+			problems = append(problems, fmt.Sprintf("%s: missing required key '%s' for type '%s'", path, key, storeType))
+		}
+	}
+	sort.Strings(problems)
+
+	return problems
+}
+
+// parseRuntimeConfigOptions parses the comma separated `name=value` options of a store flag. Values of the form
+// `secret:name:key` or `configmap:name:key` are read from a key of a Secret or ConfigMap in the same namespace.
+func parseRuntimeConfigOptions(pairs []string) ([]spinv1alpha1.RuntimeConfigOption, error) {
+	var options []spinv1alpha1.RuntimeConfigOption
+
+	for _, pair := range pairs {
+		name, value, found := strings.Cut(pair, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid option '%s'; expected name=value", pair)
+		}
+
+		option := spinv1alpha1.RuntimeConfigOption{Name: name}
+
+		source, ref, _ := strings.Cut(value, ":")
+		switch source {
+		case "secret":
+			secretName, key, err := parseKeyReference(ref)
+			if err != nil {
+				return nil, fmt.Errorf("invalid option '%s'; expected %s=secret:secretName:key", pair, name)
+			}
+
+			option.ValueFrom = &spinv1alpha1.RuntimeConfigVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+				},
+			}
+		case "configmap":
+			configMapName, key, err := parseKeyReference(ref)
+			if err != nil {
+				return nil, fmt.Errorf("invalid option '%s'; expected %s=configmap:configMapName:key", pair, name)
+			}
+
+			option.ValueFrom = &spinv1alpha1.RuntimeConfigVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+					Key:                  key,
+				},
+			}
+		default:
+			option.Value = value
+		}
+
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+// parseRuntimeConfigStore parses a store flag of the form `label=type,option=value,...` and checks that the type is
+// known and all its required options are set.
+func parseRuntimeConfigStore(flag, value string, types map[string][]string) (string, string, []spinv1alpha1.RuntimeConfigOption, error) {
+	parts := strings.Split(value, ",")
+	label, storeType, found := strings.Cut(parts[0], "=")
+	if !found || label == "" || storeType == "" {
+		return "", "", nil, fmt.Errorf("invalid value '%s' for --%s; expected label=type[,option=value...]", value, flag)
+	}
+
+	options, err := parseRuntimeConfigOptions(parts[1:])
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid value '%s' for --%s: %w", value, flag, err)
+	}
+
+	if err := validateRuntimeConfigOptions(storeType, options, types); err != nil {
+		return "", "", nil, fmt.Errorf("invalid value '%s' for --%s: %w", value, flag, err)
+	}
+
+	return label, storeType, options, nil
+}
+
+// parseLLMCompute parses the --llm-compute flag of the form `type,option=value,...`.
+func parseLLMCompute(value string) (*spinv1alpha1.LLMComputeConfig, error) {
+	parts := strings.Split(value, ",")
+	if parts[0] == "" || strings.Contains(parts[0], "=") {
+		return nil, fmt.Errorf("invalid value '%s' for --llm-compute; expected type[,option=value...]", value)
+	}
+
+	options, err := parseRuntimeConfigOptions(parts[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value '%s' for --llm-compute: %w", value, err)
+	}
+
+	if err := validateRuntimeConfigOptions(parts[0], options, llmComputeTypes); err != nil {
+		return nil, fmt.Errorf("invalid value '%s' for --llm-compute: %w", value, err)
+	}
+
+	return &spinv1alpha1.LLMComputeConfig{Type: parts[0], Options: options}, nil
+}
+
+func validateRuntimeConfigOptions(storeType string, options []spinv1alpha1.RuntimeConfigOption, types map[string][]string) error {
+	required, ok := types[storeType]
+	if !ok {
+		return fmt.Errorf("unknown type '%s'; expected one of %s", storeType, strings.Join(sortedKeys(types), ", "))
+	}
+
+	for _, key := range required {
+		if !slices.ContainsFunc(options, func(o spinv1alpha1.RuntimeConfigOption) bool { return o.Name == key }) {
+			return fmt.Errorf("missing required option '%s' for type '%s'", key, storeType)
+		}
+	}
+
+	return nil
+}
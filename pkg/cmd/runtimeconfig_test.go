@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRuntimeConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expectedError string
+	}{
+		{
+			name:    "empty file",
+			content: ``,
+		},
+		{
+			name: "known stores",
+			content: `log_dir = "/tmp"
+
+[key_value_store.default]
+type = "redis"
+url = "redis://localhost:6379"
+
+[key_value_store.cache]
+type = "spin"
+path = "/data/cache.db"
+
+[sqlite_database.default]
+type = "libsql"
+url = "https://example.turso.io"
+token = "abc"
+
+[llm_compute]
+type = "remote_http"
+url = "http://llm.local"
+auth_token = "secret"
+
+[[config_provider]]
+type = "vault"
+url = "http://vault.local"
+token = "root"
+mount = "secret"`,
+		},
+		{
+			name: "syntax error",
+			content: `[key_value_store.default]
+type = "redis
+`,
+			expectedError: "line 2, column 14: toml: basic strings cannot have new lines",
+		},
+		{
+			name: "unknown store type",
+			content: `[key_value_store.default]
+type = "redix"`,
+			expectedError: "key_value_store.default: unknown type 'redix'; expected one of aws_dynamo, azure_cosmos, redis, spin",
+		},
+		{
+			name: "missing type",
+			content: `[sqlite_database.default]
+path = "/data/db.sqlite"`,
+			expectedError: "sqlite_database.default: missing required key 'type'",
+		},
+		{
+			name: "missing required keys",
+			content: `[key_value_store.default]
+type = "redis"
+
+[sqlite_database.default]
+type = "libsql"
+url = "https://example.turso.io"`,
+			expectedError: "key_value_store.default: missing required key 'url' for type 'redis'; sqlite_database.default: missing required key 'token' for type 'libsql'",
+		},
+		{
+			name: "config provider",
+			content: `[[config_provider]]
+type = "vault"
+url = "http://vault.local"`,
+			expectedError: "config_provider[0]: missing required key 'mount' for type 'vault'; config_provider[0]: missing required key 'token' for type 'vault'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRuntimeConfig([]byte(tc.content))
+			if tc.expectedError == "" {
+				require.Nil(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
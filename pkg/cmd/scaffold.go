@@ -2,15 +2,24 @@ package cmd
 
 import (
 	"bytes"
-	"encoding/base64"
+	"cmp"
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strings"
-	"text/template"
 
 	dockerparser "github.com/novln/docker-parser"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/spinkube/spin-plugin-kube/pkg/keda"
+	"github.com/spinkube/spin-plugin-kube/pkg/prompt"
+	"golang.org/x/term"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type ScaffoldOptions struct {
@@ -18,18 +27,59 @@ type ScaffoldOptions struct {
 	configfile                        string
 	cpuLimit                          string
 	cpuRequest                        string
+	disableCPUAutoscaling             bool
+	disableMemoryAutoscaling          bool
+	environmentOverrides              []string
+	environments                      []string
 	executor                          string
 	from                              string
+	fromManifest                      string
+	interactive                       bool
 	imagePullSecrets                  []string
+	helmChart                         string
+	hpaMetrics                        []string
+	hpaScaleDownPolicies              []string
+	hpaScaleDownStabilizationWindow   *int32
+	hpaScaleUpPolicies                []string
+	hpaScaleUpStabilizationWindow     *int32
+	kedaHTTPHosts                     []string
+	kedaHTTPInterceptorNamespace      string
+	kedaHTTPPathPrefixes              []string
+	kedaHTTPScaledownPeriod           int32
+	kedaHTTPTargetPendingRequests     int32
+	kedaTriggerAuth                   map[string]string
+	kedaTriggers                      []string
+	keyValueStores                    []string
+	kustomize                         string
+	livenessPath                      string
+	llmCompute                        string
 	maxReplicas                       int32
+	metadata                          metadataOptions
 	memoryLimit                       string
 	memoryRequest                     string
+	name                              string
+	namespace                         string
 	output                            string
+	probeFailureThreshold             int32
+	probeInitialDelaySeconds          int32
+	probePeriodSeconds                int32
+	probeTimeoutSeconds               int32
+	readinessPath                     string
 	replicas                          int32
+	skipRuntimeConfigValidation       bool
+	sqliteDatabases                   []string
 	targetCPUUtilizationPercentage    int32
 	targetMemoryUtilizationPercentage int32
 	variables                         map[string]string
+	variablesFile                     string
+	variablesFromConfigMap            map[string]string
+	variablesFromSecret               map[string]string
 	components                        []string
+	persistentVolumeClaims            []string
+	pinDigest                         bool
+	validateCluster                   bool
+	volumeMounts                      []string
+	volumes                           []string
 }
 
 var scaffoldOpts = ScaffoldOptions{}
@@ -38,139 +88,91 @@ type appConfig struct {
 	Autoscaler                        string
 	CPULimit                          string
 	CPURequest                        string
+	Checks                            spinv1alpha1.HealthChecks
+	DisableCPUAutoscaling             bool
+	DisableMemoryAutoscaling          bool
 	Executor                          string
 	Image                             string
 	ImagePullSecrets                  []string
+	ImageTag                          string
+	HPABehavior                       *autoscalingv2.HorizontalPodAutoscalerBehavior
+	HPAMetrics                        []autoscalingv2.MetricSpec
+	KedaHTTPHosts                     []string
+	KedaHTTPInterceptorNamespace      string
+	KedaHTTPPathPrefixes              []string
+	KedaHTTPScaledownPeriod           int32
+	KedaHTTPTargetPendingRequests     int32
+	KedaTriggerAuth                   map[string]string
+	KedaTriggers                      []keda.ScaleTriggers
+	KeyValueStores                    []spinv1alpha1.KeyValueStoreConfig
+	LLMCompute                        *spinv1alpha1.LLMComputeConfig
 	MaxReplicas                       int32
+	Metadata                          metadataOptions
 	MemoryLimit                       string
 	MemoryRequest                     string
 	Name                              string
+	Namespace                         string
 	Replicas                          int32
-	RuntimeConfig                     string
+	RuntimeConfig                     []byte
+	SqliteDatabases                   []spinv1alpha1.SqliteDatabaseConfig
 	TargetCPUUtilizationPercentage    int32
 	TargetMemoryUtilizationPercentage int32
-	Variables                         map[string]string
+	Variables                         []spinv1alpha1.SpinVar
 	Components                        []string
+	PersistentVolumeClaims            []*corev1.PersistentVolumeClaim
+	VolumeMounts                      []corev1.VolumeMount
+	Volumes                           []corev1.Volume
 }
 
-var manifestStr = `apiVersion: core.spinkube.dev/v1alpha1
-kind: SpinApp
-metadata:
-  name: {{ .Name }}
-spec:
-  image: "{{ .Image }}"
-  executor: {{ .Executor }}
-{{- if not (eq .Autoscaler "") }}
-  enableAutoscaling: true
-{{- else }}
-  replicas: {{ .Replicas }}
-{{- end}}
-{{- if .Variables }}
-  variables:
-{{- range $key, $value := .Variables }}
-  - name: {{ $key }}
-    value: {{ $value }}
-{{- end }}
-{{- end }}
-{{- if .Components }}
-  components:
-{{- range $c := .Components }}
-  - {{ $c }}
-{{- end }}
-{{- end }}
-{{- if or .CPULimit .MemoryLimit }}
-  resources:
-    limits:
-    {{- if .CPULimit }}
-      cpu: {{ .CPULimit }}
-    {{- end }}
-    {{- if .MemoryLimit }}
-      memory: {{ .MemoryLimit }}
-    {{- end }}
-{{- if or .CPURequest .MemoryRequest }}
-    requests:
-    {{- if .CPURequest }}
-      cpu: {{ .CPURequest }}
-    {{- end }}
-    {{- if .MemoryRequest }}
-      memory: {{ .MemoryRequest }}
-    {{- end }}
-{{- end }}
-{{- end }}
-{{- if len .ImagePullSecrets }}
-  imagePullSecrets:
-{{- range $index, $secret := .ImagePullSecrets }}
-    - name: {{ $secret -}}
-{{ end }}
-{{- end }}
-{{- if .RuntimeConfig }}
-  runtimeConfig:
-    loadFromSecret: {{ .Name }}-runtime-config
----
-apiVersion: v1
-kind: Secret
-metadata:
-  name: {{ .Name }}-runtime-config
-type: Opaque
-data:
-  runtime-config.toml: {{ .RuntimeConfig }}
-{{- end }}
-{{- if not (eq .Autoscaler "") }}
----
-{{- if eq .Autoscaler "hpa" }}
-apiVersion: autoscaling/v2
-kind: HorizontalPodAutoscaler
-metadata:
-  name: {{ .Name }}-autoscaler
-spec:
-  scaleTargetRef:
-    apiVersion: apps/v1
-    kind: Deployment
-    name: {{ .Name }}
-  minReplicas: {{ .Replicas }}
-  maxReplicas: {{ .MaxReplicas }}
-  metrics:
-  - type: Resource
-    resource:
-      name: cpu
-      target:
-        type: Utilization
-        averageUtilization: {{ .TargetCPUUtilizationPercentage }}
-  - type: Resource
-    resource:
-      name: memory
-      target:
-        type: Utilization
-        averageUtilization: {{ .TargetMemoryUtilizationPercentage }}
-{{- else if eq .Autoscaler "keda" }}
-apiVersion: keda.sh/v1alpha1
-kind: ScaledObject
-metadata:
-  name: {{ .Name }}-autoscaler
-spec:
-  scaleTargetRef:
-    apiVersion: apps/v1
-    kind: Deployment
-    name: {{ .Name }}
-  minReplicaCount: {{ .Replicas }}
-  maxReplicaCount: {{ .MaxReplicas }}
-  triggers:
-  - type: cpu
-    metricType: Utilization
-    metadata:
-      value: "{{ .TargetCPUUtilizationPercentage }}"
-  - type: memory
-    metricType: Utilization
-    metadata:
-      value: "{{ .TargetMemoryUtilizationPercentage }}"
-{{- end }}
-{{- end }}
-`
-
 var scaffoldCmd = &cobra.Command{
 	Use:   "scaffold",
 	Short: "Scaffold application manifest",
-	RunE: func(_ *cobra.Command, _ []string) error {
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if scaffoldOpts.interactive || (scaffoldOpts.from == "" && term.IsTerminal(int(os.Stdin.Fd()))) {
+			opts, explicit, err := runScaffoldWizard(prompt.New(os.Stdin, os.Stderr), scaffoldOpts)
+			if err != nil {
+				return err
+			}
+
+			cmd.Flags().Visit(func(f *pflag.Flag) {
+				explicit = append(explicit, f.Name)
+			})
+
+			scaffoldOpts = opts
+			fmt.Fprintf(os.Stderr, "\nEquivalent command:\n  %s\n\n", equivalentCommand(scaffoldOpts, explicit...))
+		}
+
+		if scaffoldOpts.from == "" {
+			return fmt.Errorf(`required flag(s) "from" not set`)
+		}
+
+		if scaffoldOpts.validateCluster {
+			warnIfExecutorMissing(context.TODO(), cmp.Or(scaffoldOpts.namespace, namespace), scaffoldOpts.executor)
+		}
+
+		if (len(scaffoldOpts.environments) > 0 || len(scaffoldOpts.environmentOverrides) > 0) && scaffoldOpts.kustomize == "" {
+			return fmt.Errorf("--env and --env-flag require --kustomize")
+		}
+
+		if scaffoldOpts.kustomize != "" {
+			if err := scaffoldKustomize(scaffoldOpts); err != nil {
+				return err
+			}
+
+			log.Printf("\nKustomize base and overlays saved to %s\n", scaffoldOpts.kustomize)
+			return nil
+		}
+
+		if scaffoldOpts.helmChart != "" {
+			if err := scaffoldHelmChart(scaffoldOpts); err != nil {
+				return err
+			}
+
+			log.Printf("\nHelm chart saved to %s\n", scaffoldOpts.helmChart)
+			return nil
+		}
+
 		content, err := scaffold(scaffoldOpts)
 		if err != nil {
 			return err
@@ -200,8 +202,38 @@ func validateFlags(opts ScaffoldOptions) error {
 	}
 
 	// check that the image reference is valid
-	if !validateImageReference(opts.from) {
-		return fmt.Errorf("invalid image reference provided: '%s'", opts.from)
+	// the repository is lowercased in the generated resources, as registries only accept lowercase repositories
+	if err := checkImageReference(opts.from); err != nil {
+		return err
+	}
+
+	if opts.name != "" {
+		if err := validateName("--name", opts.name); err != nil {
+			return err
+		}
+	}
+
+	if opts.namespace != "" {
+		if err := validateName("--namespace", opts.namespace); err != nil {
+			return err
+		}
+	}
+
+	if err := validateResourceFlags(opts); err != nil {
+		return err
+	}
+
+	if err := validateProbeFlags(opts); err != nil {
+		return err
+	}
+
+	if err := opts.metadata.validate(); err != nil {
+		return err
+	}
+
+	// the operator ignores all other runtime config when it is loaded from a secret
+	if opts.configfile != "" && (len(opts.keyValueStores) > 0 || len(opts.sqliteDatabases) > 0 || opts.llmCompute != "") {
+		return fmt.Errorf("--runtime-config-file cannot be combined with --key-value-store, --sqlite-database or --llm-compute")
 	}
 
 	// validate autoscaling flags
@@ -209,8 +241,8 @@ func validateFlags(opts ScaffoldOptions) error {
 	// NOTE: --replicas refers to the minimum number of replicas
 	if opts.autoscaler != "" {
 		// autoscaler type must be a valid type
-		if opts.autoscaler != "hpa" && opts.autoscaler != "keda" {
-			return fmt.Errorf("invalid autoscaler type '%s'; the autoscaler type must be either 'hpa' or 'keda'", opts.autoscaler)
+		if opts.autoscaler != "hpa" && opts.autoscaler != "keda" && opts.autoscaler != "keda-http" {
+			return fmt.Errorf("invalid autoscaler type '%s'; the autoscaler type must be one of 'hpa', 'keda' or 'keda-http'", opts.autoscaler)
 		}
 
 		// max replicas must be equal to or greater than 0 (scale down to 0 replicas is allowed)
@@ -223,42 +255,211 @@ func validateFlags(opts ScaffoldOptions) error {
 			return fmt.Errorf("the minimum replica count (%d) must be less than or equal to the maximum replica count (%d)", opts.replicas, opts.maxReplicas)
 		}
 
-		// cpu and memory limits must be set
-		if opts.cpuLimit == "" {
+	}
+
+	// the KEDA HTTP add-on scales on pending requests rather than on resource utilization
+	if opts.autoscaler == "hpa" || opts.autoscaler == "keda" {
+		// cpu and memory limits must be set for the metrics that are in use
+		if opts.cpuLimit == "" && !opts.disableCPUAutoscaling {
 			return fmt.Errorf("cpu limits must be set when autoscaling is enabled")
 		}
 
-		if opts.memoryLimit == "" {
+		if opts.memoryLimit == "" && !opts.disableMemoryAutoscaling {
 			return fmt.Errorf("memory limits must be set when autoscaling is enabled")
 		}
 
-		// TODO: cpu and memory requests must be lower than their respective cpu/memory limit
-
 		// target cpu and memory utilization must be between 1 and 100
-		if opts.targetCPUUtilizationPercentage < 1 || opts.targetCPUUtilizationPercentage > 100 {
+		if !opts.disableCPUAutoscaling && (opts.targetCPUUtilizationPercentage < 1 || opts.targetCPUUtilizationPercentage > 100) {
 			return fmt.Errorf("target cpu utilization percentage (%d) must be between 1 and 100", opts.targetCPUUtilizationPercentage)
 		}
 
-		if opts.targetMemoryUtilizationPercentage < 1 || opts.targetMemoryUtilizationPercentage > 100 {
+		if !opts.disableMemoryAutoscaling && (opts.targetMemoryUtilizationPercentage < 1 || opts.targetMemoryUtilizationPercentage > 100) {
 			return fmt.Errorf("target memory utilization percentage (%d) must be between 1 and 100", opts.targetMemoryUtilizationPercentage)
 		}
 	}
+
+	if (opts.disableCPUAutoscaling || opts.disableMemoryAutoscaling) && opts.autoscaler != "hpa" && opts.autoscaler != "keda" {
+		return fmt.Errorf("--autoscaler-disable-cpu and --autoscaler-disable-memory require --autoscaler hpa or keda")
+	}
+
+	if err := validateHPAFlags(opts); err != nil {
+		return err
+	}
+
+	if err := validateKedaFlags(opts); err != nil {
+		return err
+	}
+
+	if err := validateKedaHTTPFlags(opts); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func scaffold(opts ScaffoldOptions) ([]byte, error) {
+func validateKedaFlags(opts ScaffoldOptions) error {
+	if opts.autoscaler != "keda" {
+		if len(opts.kedaTriggers) > 0 || len(opts.kedaTriggerAuth) > 0 {
+			return fmt.Errorf("--keda-trigger and --keda-trigger-auth require --autoscaler keda")
+		}
+
+		return nil
+	}
+
+	triggers, err := parseKedaTriggers(opts)
+	if err != nil {
+		return err
+	}
+
+	if len(opts.kedaTriggerAuth) > 0 && len(triggers) == 0 {
+		return fmt.Errorf("--keda-trigger-auth requires at least one --keda-trigger")
+	}
+
+	if opts.disableCPUAutoscaling && opts.disableMemoryAutoscaling && len(triggers) == 0 {
+		return fmt.Errorf("at least one --keda-trigger is required when both cpu and memory autoscaling are disabled")
+	}
+
+	// KEDA can only scale to zero replicas based on event-driven triggers
+	if opts.replicas == 0 && len(triggers) == 0 {
+		return fmt.Errorf("scaling to zero replicas requires at least one --keda-trigger")
+	}
+
+	return nil
+}
+
+func validateKedaHTTPFlags(opts ScaffoldOptions) error {
+	if opts.autoscaler != "keda-http" {
+		if len(opts.kedaHTTPHosts) > 0 || len(opts.kedaHTTPPathPrefixes) > 0 {
+			return fmt.Errorf("--keda-http-host and --keda-http-path-prefix require --autoscaler keda-http")
+		}
+
+		return nil
+	}
+
+	if len(opts.kedaHTTPHosts) == 0 {
+		return fmt.Errorf("at least one --keda-http-host is required when using the 'keda-http' autoscaler")
+	}
+
+	for _, prefix := range opts.kedaHTTPPathPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("--keda-http-path-prefix '%s' must start with '/'", prefix)
+		}
+	}
+
+	if opts.kedaHTTPTargetPendingRequests < 1 {
+		return fmt.Errorf("the target pending requests (%d) must be greater than 0", opts.kedaHTTPTargetPendingRequests)
+	}
+
+	if opts.kedaHTTPScaledownPeriod < 0 {
+		return fmt.Errorf("the scaledown period (%d) must be equal to or greater than 0", opts.kedaHTTPScaledownPeriod)
+	}
+
+	if opts.kedaHTTPInterceptorNamespace == "" {
+		return fmt.Errorf("--keda-http-interceptor-namespace must not be empty")
+	}
+
+	return nil
+}
+
+// validateResourceFlags checks that the cpu and memory quantities are well-formed and that requests do not exceed their
+// respective limit.
+func validateResourceFlags(opts ScaffoldOptions) error {
+	for _, res := range []struct {
+		name    string
+		limit   string
+		request string
+	}{
+		{"cpu", opts.cpuLimit, opts.cpuRequest},
+		{"memory", opts.memoryLimit, opts.memoryRequest},
+	} {
+		limit, err := parseQuantityFlag(res.name+"-limit", res.limit)
+		if err != nil {
+			return err
+		}
+
+		request, err := parseQuantityFlag(res.name+"-request", res.request)
+		if err != nil {
+			return err
+		}
+
+		if res.limit != "" && res.request != "" && request.Cmp(limit) > 0 {
+			return fmt.Errorf("--%s-request (%s) must be less than or equal to --%s-limit (%s)", res.name, res.request, res.name, res.limit)
+		}
+	}
+
+	return nil
+}
+
+// parseQuantityFlag parses the quantity set with the flag, where an empty value is the zero quantity.
+func parseQuantityFlag(flag, value string) (resource.Quantity, error) {
+	if value == "" {
+		return resource.Quantity{}, nil
+	}
+
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid value '%s' for --%s: %w", value, flag, err)
+	}
+
+	return quantity, nil
+}
+
+func validateProbeFlags(opts ScaffoldOptions) error {
+	for _, path := range []struct{ flag, value string }{
+		{"liveness-path", opts.livenessPath},
+		{"readiness-path", opts.readinessPath},
+	} {
+		if path.value != "" && !strings.HasPrefix(path.value, "/") {
+			return fmt.Errorf("--%s '%s' must be an absolute path starting with '/'", path.flag, path.value)
+		}
+	}
+
+	timings := []struct {
+		flag  string
+		value int32
+	}{
+		{"probe-initial-delay", opts.probeInitialDelaySeconds},
+		{"probe-period", opts.probePeriodSeconds},
+		{"probe-timeout", opts.probeTimeoutSeconds},
+		{"probe-failure-threshold", opts.probeFailureThreshold},
+	}
+
+	timingsSet := false
+	for _, timing := range timings {
+		if timing.value < 0 {
+			return fmt.Errorf("--%s (%d) must not be negative", timing.flag, timing.value)
+		}
+
+		timingsSet = timingsSet || timing.value > 0
+	}
+
+	if timingsSet && opts.livenessPath == "" && opts.readinessPath == "" {
+		return fmt.Errorf("probe timings require --liveness-path or --readiness-path to be set")
+	}
+
+	// a probe that may take longer than the interval between probes would overlap with the next one
+	if opts.probeTimeoutSeconds > 0 && opts.probePeriodSeconds > 0 && opts.probeTimeoutSeconds > opts.probePeriodSeconds {
+		return fmt.Errorf("--probe-timeout (%d) must not be greater than --probe-period (%d)", opts.probeTimeoutSeconds, opts.probePeriodSeconds)
+	}
+
+	return nil
+}
+
+// newAppConfig validates the options and resolves them into the configuration the objects are built from.
+func newAppConfig(opts ScaffoldOptions) (appConfig, error) {
 	if err := validateFlags(opts); err != nil {
-		return nil, err
+		return appConfig{}, err
 	}
 
-	name, err := getNameFromImageReference(opts.from)
+	name, err := resolveAppName(opts.name, opts.from)
 	if err != nil {
-		return nil, err
+		return appConfig{}, err
 	}
 
 	config := appConfig{
 		Name:                              name,
-		Image:                             opts.from,
+		Namespace:                         opts.namespace,
+		Image:                             lowercaseRepository(opts.from),
 		Replicas:                          opts.replicas,
 		MaxReplicas:                       opts.maxReplicas,
 		Executor:                          opts.executor,
@@ -270,73 +471,275 @@ func scaffold(opts ScaffoldOptions) ([]byte, error) {
 		TargetMemoryUtilizationPercentage: opts.targetMemoryUtilizationPercentage,
 		Autoscaler:                        opts.autoscaler,
 		ImagePullSecrets:                  opts.imagePullSecrets,
-		Variables:                         opts.variables,
 		Components:                        opts.components,
+		Metadata:                          opts.metadata,
+		DisableCPUAutoscaling:             opts.disableCPUAutoscaling,
+		DisableMemoryAutoscaling:          opts.disableMemoryAutoscaling,
+		KedaTriggerAuth:                   opts.kedaTriggerAuth,
+		KedaHTTPHosts:                     opts.kedaHTTPHosts,
+		KedaHTTPPathPrefixes:              opts.kedaHTTPPathPrefixes,
+		KedaHTTPTargetPendingRequests:     opts.kedaHTTPTargetPendingRequests,
+		KedaHTTPScaledownPeriod:           opts.kedaHTTPScaledownPeriod,
+		KedaHTTPInterceptorNamespace:      opts.kedaHTTPInterceptorNamespace,
+	}
+
+	if opts.pinDigest {
+		config.Image, config.ImageTag, err = pinImageDigest(context.TODO(), config.Image, cmp.Or(opts.namespace, namespace), opts.imagePullSecrets)
+		if err != nil {
+			return appConfig{}, err
+		}
+	}
+
+	if opts.livenessPath != "" {
+		config.Checks.Liveness = newHealthProbe(opts, opts.livenessPath)
+	}
+
+	if opts.readinessPath != "" {
+		config.Checks.Readiness = newHealthProbe(opts, opts.readinessPath)
+	}
+
+	var manifest *spinManifest
+	if opts.fromManifest != "" {
+		manifest, err = loadSpinManifest(opts.fromManifest)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		// explicitly selected components take precedence over the ones declared in the manifest
+		if len(config.Components) == 0 {
+			config.Components = manifest.Components
+		}
+	}
+
+	config.Variables, err = resolveVariables(opts, manifest)
+	if err != nil {
+		return appConfig{}, err
+	}
+
+	for _, value := range opts.keyValueStores {
+		label, storeType, options, err := parseRuntimeConfigStore("key-value-store", value, keyValueStoreTypes)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		if slices.ContainsFunc(config.KeyValueStores, func(c spinv1alpha1.KeyValueStoreConfig) bool { return c.Name == label }) {
+			return appConfig{}, fmt.Errorf("--key-value-store '%s' is specified more than once", label)
+		}
+
+		config.KeyValueStores = append(config.KeyValueStores, spinv1alpha1.KeyValueStoreConfig{Name: label, Type: storeType, Options: options})
+	}
+
+	for _, value := range opts.sqliteDatabases {
+		label, storeType, options, err := parseRuntimeConfigStore("sqlite-database", value, sqliteDatabaseTypes)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		if slices.ContainsFunc(config.SqliteDatabases, func(c spinv1alpha1.SqliteDatabaseConfig) bool { return c.Name == label }) {
+			return appConfig{}, fmt.Errorf("--sqlite-database '%s' is specified more than once", label)
+		}
+
+		config.SqliteDatabases = append(config.SqliteDatabases, spinv1alpha1.SqliteDatabaseConfig{Name: label, Type: storeType, Options: options})
+	}
+
+	if opts.llmCompute != "" {
+		config.LLMCompute, err = parseLLMCompute(opts.llmCompute)
+		if err != nil {
+			return appConfig{}, err
+		}
+	}
+
+	if opts.autoscaler == "hpa" {
+		config.HPAMetrics, err = parseHPAMetrics(opts)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		config.HPABehavior, err = newHPABehavior(opts)
+		if err != nil {
+			return appConfig{}, err
+		}
 	}
 
-	if opts.configfile != "" {
-		raw, readErr := os.ReadFile(opts.configfile)
-		if readErr != nil {
-			return nil, readErr
+	if opts.autoscaler == "keda" {
+		config.KedaTriggers, err = parseKedaTriggers(opts)
+		if err != nil {
+			return appConfig{}, err
+		}
+	}
+
+	for _, value := range opts.volumes {
+		volume, err := parseVolume(value)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		if slices.ContainsFunc(config.Volumes, func(v corev1.Volume) bool { return v.Name == volume.Name }) {
+			return appConfig{}, fmt.Errorf("--volume '%s' is specified more than once", volume.Name)
 		}
 
-		config.RuntimeConfig = base64.StdEncoding.EncodeToString(raw)
+		config.Volumes = append(config.Volumes, volume)
 	}
 
-	tmpl, err := template.New("spinapp").Parse(manifestStr)
+	for _, value := range opts.volumeMounts {
+		mount, err := parseVolumeMount(value)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		if !slices.ContainsFunc(config.Volumes, func(v corev1.Volume) bool { return v.Name == mount.Name }) {
+			return appConfig{}, fmt.Errorf("--volume-mount '%s' refers to volume '%s', which is not declared with --volume", value, mount.Name)
+		}
+
+		config.VolumeMounts = append(config.VolumeMounts, mount)
+	}
+
+	for _, value := range opts.persistentVolumeClaims {
+		pvc, err := newPersistentVolumeClaim(value)
+		if err != nil {
+			return appConfig{}, err
+		}
+
+		config.PersistentVolumeClaims = append(config.PersistentVolumeClaims, pvc)
+	}
+
+	for _, warning := range singleNodeClaimWarnings(config) {
+		log.Printf("warning: %s\n", warning)
+	}
+
+	config.RuntimeConfig, err = loadRuntimeConfig(opts)
+	if err != nil {
+		return appConfig{}, err
+	}
+
+	return config, nil
+}
+
+// loadRuntimeConfig reads the runtime config file, if one is set, and validates it unless validation is skipped.
+func loadRuntimeConfig(opts ScaffoldOptions) ([]byte, error) {
+	if opts.configfile == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(opts.configfile)
 	if err != nil {
 		return nil, err
 	}
 
-	var output bytes.Buffer
-	err = tmpl.Execute(&output, config)
+	if !opts.skipRuntimeConfigValidation {
+		if err := validateRuntimeConfig(raw); err != nil {
+			return nil, fmt.Errorf("invalid runtime config file %s: %w", opts.configfile, err)
+		}
+	}
+
+	return raw, nil
+}
+
+func scaffold(opts ScaffoldOptions) ([]byte, error) {
+	config, err := newAppConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := buildObjects(config)
 	if err != nil {
 		return nil, err
 	}
 
+	var output bytes.Buffer
+	if err := printObjects(&output, objects...); err != nil {
+		return nil, err
+	}
+
 	return output.Bytes(), nil
 }
 
+// scaffoldHelmChart writes a Helm chart to opts.helmChart that renders the scaffold output with its default values.
+func scaffoldHelmChart(opts ScaffoldOptions) error {
+	config, err := newAppConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	objects, err := buildObjects(config)
+	if err != nil {
+		return err
+	}
+
+	return writeHelmChart(opts.helmChart, config, objects)
+}
+
 func validateImageReference(imageRef string) bool {
 	_, err := dockerparser.Parse(imageRef)
 	return err == nil
 }
 
-func getNameFromImageReference(imageRef string) (string, error) {
-	ref, err := dockerparser.Parse(imageRef)
-	if err != nil {
-		return "", err
-	}
+// addFlags registers the flags that configure the generated resources.
+func (o *ScaffoldOptions) addFlags(flags *pflag.FlagSet) {
+	flags.Int32VarP(&o.replicas, "replicas", "r", 2, "Minimum number of replicas for the application")
+	flags.Int32Var(&o.maxReplicas, "max-replicas", 3, "Maximum number of replicas for the application. Autoscaling must be enabled to use this flag")
+	flags.Int32Var(&o.targetCPUUtilizationPercentage, "autoscaler-target-cpu-utilization", 60, "The target CPU utilization percentage to maintain across all pods")
+	flags.Int32Var(&o.targetMemoryUtilizationPercentage, "autoscaler-target-memory-utilization", 60, "The target memory utilization percentage to maintain across all pods")
+	flags.StringVar(&o.autoscaler, "autoscaler", "", "The autoscaler to use. Valid values are 'hpa', 'keda' and 'keda-http'")
+	flags.BoolVar(&o.disableCPUAutoscaling, "autoscaler-disable-cpu", false, "Do not scale on CPU utilization. Only supported with the 'hpa' and 'keda' autoscalers")
+	flags.BoolVar(&o.disableMemoryAutoscaling, "autoscaler-disable-memory", false, "Do not scale on memory utilization. Only supported with the 'hpa' and 'keda' autoscalers")
+	flags.StringArrayVar(&o.hpaMetrics, "hpa-metric", nil, "Additional HPA metric (type=<pods|object|external>,name=<metric>,<value|averageValue>=<quantity>[,describedObject=<apiVersion>/<kind>/<name>][,selector.<label>=<value>...]). Values that contain commas must be enclosed in double quotes. This can be specified multiple times")
+	flags.Var(optionalInt32Value{&o.hpaScaleUpStabilizationWindow}, "hpa-scale-up-stabilization-window", "Number of seconds of past recommendations the HPA considers when scaling up. When not set, the Kubernetes default of 0 applies")
+	flags.Var(optionalInt32Value{&o.hpaScaleDownStabilizationWindow}, "hpa-scale-down-stabilization-window", "Number of seconds of past recommendations the HPA considers when scaling down. When not set, the Kubernetes default of 300 applies")
+	flags.StringArrayVar(&o.hpaScaleUpPolicies, "hpa-scale-up-policy", nil, "HPA scale up policy (<pods|percent>=<value>,period=<seconds>). This can be specified multiple times")
+	flags.StringArrayVar(&o.hpaScaleDownPolicies, "hpa-scale-down-policy", nil, "HPA scale down policy (<pods|percent>=<value>,period=<seconds>). This can be specified multiple times")
+	flags.StringArrayVar(&o.kedaTriggers, "keda-trigger", nil, "Event-driven KEDA trigger (type=<scaler>[,name=<name>][,metricType=<type>],key=value...), e.g. type=redis,address=redis:6379,listName=jobs,listLength=10. Values that contain commas must be enclosed in double quotes. This can be specified multiple times")
+	flags.StringToStringVar(&o.kedaTriggerAuth, "keda-trigger-auth", nil, "Trigger authentication parameter (parameter=secretName:key) read from a Secret. Generates a TriggerAuthentication used by all --keda-trigger triggers")
+	flags.StringSliceVar(&o.kedaHTTPHosts, "keda-http-host", nil, "Host routed to the application by the KEDA HTTP add-on interceptor. This can be specified multiple times")
+	flags.StringSliceVar(&o.kedaHTTPPathPrefixes, "keda-http-path-prefix", nil, "Path prefix routed to the application by the KEDA HTTP add-on interceptor. This can be specified multiple times")
+	flags.Int32Var(&o.kedaHTTPTargetPendingRequests, "keda-http-target-pending-requests", 100, "The number of pending requests per replica the KEDA HTTP add-on scales on")
+	flags.Int32Var(&o.kedaHTTPScaledownPeriod, "keda-http-scaledown-period", 300, "Number of seconds without traffic before the KEDA HTTP add-on scales the application down")
+	flags.StringVar(&o.kedaHTTPInterceptorNamespace, "keda-http-interceptor-namespace", "keda", "The namespace the KEDA HTTP add-on is installed in")
+	flags.StringVar(&o.executor, "executor", defaultExecutorName, "The executor used to run the application")
+	flags.StringVar(&o.cpuLimit, "cpu-limit", "", "The maximum amount of CPU resource units the application is allowed to use")
+	flags.StringVar(&o.cpuRequest, "cpu-request", "", "The amount of CPU resource units requested by the application. Used to determine which node the application will run on")
+	flags.StringVar(&o.memoryLimit, "memory-limit", "", "The maximum amount of memory the application is allowed to use")
+	flags.StringVar(&o.memoryRequest, "memory-request", "", "The amount of memory requested by the application. Used to determine which node the application will run on")
+	flags.StringVarP(&o.from, "from", "f", "", "Reference in the registry of the application")
+	flags.StringVar(&o.name, "name", "", "Name of the application. Defaults to the image name, converted to a valid resource name")
+	flags.StringVar(&o.fromManifest, "from-manifest", "", "Path to the Spin manifest (spin.toml), or to the directory that contains it, used to populate components and variables, e.g. '.' for the current directory")
+	flags.StringVarP(&o.configfile, "runtime-config-file", "c", "", "Path to runtime config file")
+	flags.BoolVar(&o.skipRuntimeConfigValidation, "skip-runtime-config-validation", false, "Embed the runtime config file without checking that Spin can load it")
+	flags.StringArrayVar(&o.keyValueStores, "key-value-store", nil, "Key value store (label=type[,option=value...]) available to the application. Option values of the form secret:name:key or configmap:name:key are read from a Secret or ConfigMap. This can be specified multiple times")
+	flags.StringArrayVar(&o.sqliteDatabases, "sqlite-database", nil, "SQLite database (label=type[,option=value...]) available to the application. Option values of the form secret:name:key or configmap:name:key are read from a Secret or ConfigMap. This can be specified multiple times")
+	flags.StringVar(&o.llmCompute, "llm-compute", "", "LLM compute (type[,option=value...]) available to the application. Option values of the form secret:name:key or configmap:name:key are read from a Secret or ConfigMap")
+	flags.StringVar(&o.livenessPath, "liveness-path", "", "HTTP path of the liveness probe. Defaults to the operator's liveness probe when not set")
+	flags.StringVar(&o.readinessPath, "readiness-path", "", "HTTP path of the readiness probe. Defaults to the operator's readiness probe when not set")
+	flags.Int32Var(&o.probeInitialDelaySeconds, "probe-initial-delay", 0, "Number of seconds after the container has started before the liveness and readiness probes are initiated")
+	flags.Int32Var(&o.probePeriodSeconds, "probe-period", 0, "How often (in seconds) to perform the liveness and readiness probes")
+	flags.Int32Var(&o.probeTimeoutSeconds, "probe-timeout", 0, "Number of seconds after which the liveness and readiness probes time out")
+	flags.Int32Var(&o.probeFailureThreshold, "probe-failure-threshold", 0, "Number of consecutive failures after which a liveness or readiness probe is considered failed")
+	flags.StringArrayVar(&o.volumes, "volume", nil, "Volume (name=pvc:claimName, name=configmap:configMapName, name=secret:secretName or name=emptyDir) available to the application. This can be specified multiple times")
+	flags.StringArrayVar(&o.volumeMounts, "volume-mount", nil, "Mount a volume declared with --volume into the application (name:/path[:ro]). This can be specified multiple times")
+	flags.StringArrayVar(&o.persistentVolumeClaims, "create-pvc", nil, "Generate a PersistentVolumeClaim (name=size[,storageClass][,accessMode]) alongside the application. The access mode defaults to ReadWriteOnce, which can only be mounted by the pods of a single node. This can be specified multiple times")
+	o.metadata.addFlags(flags)
+	flags.StringSliceVarP(&o.imagePullSecrets, "image-pull-secret", "s", []string{}, "Secrets in the same namespace to use for pulling the image")
+	flags.BoolVar(&o.pinDigest, "pin-digest", false, "Resolve the image tag against the registry and reference the image by its digest")
+	flags.StringToStringVarP(&o.variables, "variable", "v", nil, "Application variable (name=value) to be provided to the application")
+	flags.StringToStringVar(&o.variablesFromSecret, "variable-from-secret", nil, "Application variable (name=secretName:key) whose value is read from a key of a Secret in the same namespace")
+	flags.StringToStringVar(&o.variablesFromConfigMap, "variable-from-configmap", nil, "Application variable (name=configMapName:key) whose value is read from a key of a ConfigMap in the same namespace")
+	flags.StringVar(&o.variablesFile, "variables-file", "", "Path to a YAML (.yaml, .yml) or dotenv (.env) file with application variables. Values provided with --variable take precedence")
+	flags.StringSliceVarP(&o.components, "component", "", nil, "Component ID to run. This can be specified multiple times. The default is all components.")
 
-	if strings.Contains(ref.ShortName(), "/") {
-		parts := strings.Split(ref.ShortName(), "/")
-		return parts[len(parts)-1], nil
-	}
-
-	return ref.ShortName(), nil
 }
 
 func init() {
-	scaffoldCmd.Flags().Int32VarP(&scaffoldOpts.replicas, "replicas", "r", 2, "Minimum number of replicas for the application")
-	scaffoldCmd.Flags().Int32Var(&scaffoldOpts.maxReplicas, "max-replicas", 3, "Maximum number of replicas for the application. Autoscaling must be enabled to use this flag")
-	scaffoldCmd.Flags().Int32Var(&scaffoldOpts.targetCPUUtilizationPercentage, "autoscaler-target-cpu-utilization", 60, "The target CPU utilization percentage to maintain across all pods")
-	scaffoldCmd.Flags().Int32Var(&scaffoldOpts.targetMemoryUtilizationPercentage, "autoscaler-target-memory-utilization", 60, "The target memory utilization percentage to maintain across all pods")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.autoscaler, "autoscaler", "", "The autoscaler to use. Valid values are 'hpa' and 'keda'")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.executor, "executor", "containerd-shim-spin", "The executor used to run the application")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.cpuLimit, "cpu-limit", "", "The maximum amount of CPU resource units the application is allowed to use")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.cpuRequest, "cpu-request", "", "The amount of CPU resource units requested by the application. Used to determine which node the application will run on")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.memoryLimit, "memory-limit", "", "The maximum amount of memory the application is allowed to use")
-	scaffoldCmd.Flags().StringVar(&scaffoldOpts.memoryRequest, "memory-request", "", "The amount of memory requested by the application. Used to determine which node the application will run on")
-	scaffoldCmd.Flags().StringVarP(&scaffoldOpts.from, "from", "f", "", "Reference in the registry of the application")
+	scaffoldOpts.addFlags(scaffoldCmd.Flags())
+	scaffoldCmd.Flags().StringVarP(&scaffoldOpts.namespace, "namespace", "n", "", "Namespace of the generated resources. Defaults to the namespace they are applied to")
 	scaffoldCmd.Flags().StringVarP(&scaffoldOpts.output, "out", "o", "", "Path to file to write manifest yaml")
-	scaffoldCmd.Flags().StringVarP(&scaffoldOpts.configfile, "runtime-config-file", "c", "", "Path to runtime config file")
-	scaffoldCmd.Flags().StringSliceVarP(&scaffoldOpts.imagePullSecrets, "image-pull-secret", "s", []string{}, "Secrets in the same namespace to use for pulling the image")
-	scaffoldCmd.PersistentFlags().StringToStringVarP(&scaffoldOpts.variables, "variable", "v", nil, "Application variable (name=value) to be provided to the application")
-	scaffoldCmd.PersistentFlags().StringSliceVarP(&scaffoldOpts.components, "component", "", nil, "Component ID to run. This can be specified multiple times. The default is all components.")
-
-	if err := scaffoldCmd.MarkFlagRequired("from"); err != nil {
-		log.Fatal(err)
-	}
+	scaffoldCmd.Flags().StringVar(&scaffoldOpts.helmChart, "helm-chart", "", "Path to a directory to write a Helm chart to instead of the manifest yaml")
+	scaffoldCmd.Flags().StringVar(&scaffoldOpts.kustomize, "kustomize", "", "Path to a directory to write a kustomize base and overlays to instead of the manifest yaml")
+	scaffoldCmd.Flags().StringArrayVar(&scaffoldOpts.environments, "env", nil, "Environment to write a kustomize overlay for. This can be specified multiple times")
+	scaffoldCmd.Flags().StringArrayVar(&scaffoldOpts.environmentOverrides, "env-flag", nil, "Scaffold flag (<env>:<flag>=<value>) that only applies to the overlay of an environment, e.g. prod:replicas=5. This can be specified multiple times")
+	scaffoldCmd.Flags().BoolVar(&scaffoldOpts.validateCluster, "validate-cluster", false, "Warn about objects the application depends on that do not exist in the cluster, such as the executor")
+	scaffoldCmd.Flags().BoolVar(&scaffoldOpts.interactive, "interactive", false, "Ask for the options in a wizard. This is the default on a terminal when --from is not set")
+
+	scaffoldCmd.MarkFlagsMutuallyExclusive("out", "helm-chart", "kustomize")
 
 	rootCmd.AddCommand(scaffoldCmd)
 }
@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func TestScaffoldOutput(t *testing.T) {
@@ -111,6 +118,255 @@ func TestScaffoldOutput(t *testing.T) {
 			},
 			expected: "components.yml",
 		},
+		{
+			name: "components and variables from a v1 manifest",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				replicas:     2,
+				executor:     "containerd-shim-spin",
+				fromManifest: "testdata/spin-v1.toml",
+			},
+			expected: "from_manifest.yml",
+		},
+		{
+			name: "flags take precedence over a v2 manifest",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				replicas:     2,
+				executor:     "containerd-shim-spin",
+				fromManifest: "testdata/spin-v2.toml",
+				variables: map[string]string{
+					"api_key": "s3cr3t",
+				},
+				components: []string{
+					"hello",
+				},
+			},
+			expected: "from_manifest_overrides.yml",
+		},
+		{
+			name: "variable values that need quoting",
+			opts: ScaffoldOptions{
+				from:     "ghcr.io/foo/example-app:v0.1.0",
+				replicas: 2,
+				executor: "containerd-shim-spin",
+				variables: map[string]string{
+					"colon":     "a: b",
+					"alias":     "*foo",
+					"flow":      "{foo: bar}",
+					"boolean":   "true",
+					"number":    "1.0",
+					"multiline": "first\nsecond",
+					"comment":   "foo # bar",
+				},
+			},
+			expected: "variables_quoting.yml",
+		},
+		{
+			name: "variables from secrets and configmaps",
+			opts: ScaffoldOptions{
+				from:     "ghcr.io/foo/example-app:v0.1.0",
+				replicas: 2,
+				executor: "containerd-shim-spin",
+				variables: map[string]string{
+					"greeting": "hello",
+				},
+				variablesFromSecret: map[string]string{
+					"api_key": "example-app-credentials:api-key",
+				},
+				variablesFromConfigMap: map[string]string{
+					"log_level": "example-app-config:log-level",
+				},
+			},
+			expected: "variables_from_refs.yml",
+		},
+		{
+			name: "variables from a YAML file",
+			opts: ScaffoldOptions{
+				from:          "ghcr.io/foo/example-app:v0.1.0",
+				replicas:      2,
+				executor:      "containerd-shim-spin",
+				variablesFile: "testdata/variables.yaml",
+				variables: map[string]string{
+					"greeting": "hi",
+				},
+			},
+			expected: "variables_file.yml",
+		},
+		{
+			name: "variables from a dotenv file",
+			opts: ScaffoldOptions{
+				from:          "ghcr.io/foo/example-app:v0.1.0",
+				replicas:      2,
+				executor:      "containerd-shim-spin",
+				variablesFile: "testdata/variables.env",
+			},
+			expected: "variables_dotenv.yml",
+		},
+		{
+			name: "typed runtime config stores",
+			opts: ScaffoldOptions{
+				from:     "ghcr.io/foo/example-app:v0.1.0",
+				replicas: 2,
+				executor: "containerd-shim-spin",
+				keyValueStores: []string{
+					"default=redis,url=secret:redis-creds:url",
+					"cache=spin",
+				},
+				sqliteDatabases: []string{
+					"default=libsql,url=https://example.turso.io,token=secret:turso-creds:token",
+				},
+				llmCompute: "remote_http,url=configmap:llm-config:url,auth_token=secret:llm-creds:token",
+			},
+			expected: "runtime_config_stores.yml",
+		},
+		{
+			name: "health checks",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				replicas:                 2,
+				executor:                 "containerd-shim-spin",
+				livenessPath:             "/healthz",
+				readinessPath:            "/readyz",
+				probeInitialDelaySeconds: 5,
+				probePeriodSeconds:       10,
+				probeTimeoutSeconds:      2,
+				probeFailureThreshold:    3,
+			},
+			expected: "health_checks.yml",
+		},
+		{
+			name: "volumes and persistent volume claims",
+			opts: ScaffoldOptions{
+				from:     "ghcr.io/foo/example-app:v0.1.0",
+				replicas: 2,
+				executor: "containerd-shim-spin",
+				volumes: []string{
+					"data=pvc:example-app-data",
+					"config=configmap:example-app-config",
+					"scratch=emptyDir",
+				},
+				volumeMounts: []string{
+					"data:/data",
+					"config:/config:ro",
+					"scratch:/tmp",
+				},
+				persistentVolumeClaims: []string{
+					"example-app-data=1Gi,standard",
+				},
+			},
+			expected: "volumes.yml",
+		},
+		{
+			name: "labels and annotations",
+			opts: ScaffoldOptions{
+				from:       "ghcr.io/foo/example-app:v0.1.0",
+				replicas:   2,
+				executor:   "containerd-shim-spin",
+				configfile: "testdata/runtime-config.toml",
+				metadata: metadataOptions{
+					labels:      map[string]string{"cost-center": "team-a"},
+					annotations: map[string]string{"owner": "platform"},
+					podLabels:   map[string]string{"app.kubernetes.io/part-of": "shop"},
+					podAnnotations: map[string]string{
+						"linkerd.io/inject":    "enabled",
+						"prometheus.io/scrape": "true",
+					},
+					deploymentAnnotations: map[string]string{"reloader.stakater.com/auto": "true"},
+					serviceAnnotations:    map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+				},
+			},
+			expected: "labels_annotations.yml",
+		},
+		{
+			name: "KEDA event-driven triggers",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				executor:                 "containerd-shim-spin",
+				autoscaler:               "keda",
+				replicas:                 0,
+				maxReplicas:              10,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+				kedaTriggers: []string{
+					"type=redis,address=redis.default.svc.cluster.local:6379,listName=jobs,listLength=10",
+					"type=cron,name=business-hours,timezone=Europe/Berlin,start=0 8 * * 1-5,end=0 18 * * 1-5,desiredReplicas=2",
+				},
+				kedaTriggerAuth: map[string]string{
+					"password": "redis-creds:password",
+				},
+			},
+			expected: "keda_event_triggers.yml",
+		},
+		{
+			name: "KEDA HTTP autoscaler",
+			opts: ScaffoldOptions{
+				from:                          "ghcr.io/foo/example-app:v0.1.0",
+				executor:                      "containerd-shim-spin",
+				autoscaler:                    "keda-http",
+				replicas:                      0,
+				maxReplicas:                   10,
+				kedaHTTPHosts:                 []string{"example.com", "www.example.com"},
+				kedaHTTPPathPrefixes:          []string{"/api"},
+				kedaHTTPTargetPendingRequests: 50,
+				kedaHTTPScaledownPeriod:       120,
+				kedaHTTPInterceptorNamespace:  "keda",
+			},
+			expected: "keda_http_autoscaler.yml",
+		},
+		{
+			name: "HPA scaling behavior and custom metrics",
+			opts: ScaffoldOptions{
+				from:                            "ghcr.io/foo/example-app:v0.1.0",
+				executor:                        "containerd-shim-spin",
+				autoscaler:                      "hpa",
+				cpuLimit:                        "100m",
+				replicas:                        2,
+				maxReplicas:                     10,
+				targetCPUUtilizationPercentage:  70,
+				disableMemoryAutoscaling:        true,
+				hpaScaleUpStabilizationWindow:   ptr[int32](30),
+				hpaScaleDownStabilizationWindow: ptr[int32](600),
+				hpaScaleUpPolicies:              []string{"pods=4,period=60", "percent=100,period=15"},
+				hpaScaleDownPolicies:            []string{"percent=10,period=60"},
+				hpaMetrics: []string{
+					"type=pods,name=http_requests_per_second,averageValue=100",
+					"type=object,name=requests_per_second,describedObject=networking.k8s.io/v1/Ingress/main-route,value=2k",
+					"type=external,name=queue_messages_ready,selector.queue=worker_tasks,averageValue=30",
+				},
+			},
+			expected: "hpa_behavior_metrics.yml",
+		},
+		{
+			name: "resource requests without limits",
+			opts: ScaffoldOptions{
+				from:          "ghcr.io/foo/example-app:v0.1.0",
+				executor:      "containerd-shim-spin",
+				replicas:      2,
+				cpuRequest:    "50m",
+				memoryLimit:   "128Mi",
+				memoryRequest: "64Mi",
+			},
+			expected: "resources.yml",
+		},
+		{
+			name: "name and namespace",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				executor:                          "containerd-shim-spin",
+				name:                              "storefront",
+				namespace:                         "shop",
+				autoscaler:                        "hpa",
+				cpuLimit:                          "100m",
+				memoryLimit:                       "128Mi",
+				replicas:                          2,
+				maxReplicas:                       3,
+				targetCPUUtilizationPercentage:    60,
+				targetMemoryUtilizationPercentage: 60,
+				configfile:                        "testdata/runtime-config.toml",
+			},
+			expected: "name_namespace.yml",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -121,11 +377,70 @@ func TestScaffoldOutput(t *testing.T) {
 			expectedContent, err := os.ReadFile(filepath.Join("testdata", tc.expected))
 			require.Nil(t, err)
 
-			require.Equal(t, string(expectedContent), string(output))
+			requireManifestsEqual(t, string(expectedContent), string(output))
 		})
 	}
 }
 
+// requireManifestsEqual compares two multi-document YAML manifests document by document, ignoring formatting and key
+// order.
+func requireManifestsEqual(t *testing.T, expected, actual string) {
+	expectedDocs := strings.Split(expected, "\n---\n")
+	actualDocs := strings.Split(actual, "\n---\n")
+	require.Len(t, actualDocs, len(expectedDocs), "Expected the same number of documents")
+
+	for i := range expectedDocs {
+		require.YAMLEq(t, expectedDocs[i], actualDocs[i])
+	}
+}
+
+func TestScaffoldPinDigest(t *testing.T) {
+	const digest = "sha256:6c3c624b58dbbcd3c0dd82b4c53f04194d1247c6eebdaab7c610cf7d66709b3b"
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v2/foo/example-app/manifests/v0.1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Docker-Content-Digest", digest)
+	}))
+	defer server.Close()
+
+	registryHTTPClient = server.Client()
+	defer func() { registryHTTPClient = http.DefaultClient }()
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	output, err := scaffold(ScaffoldOptions{
+		from:      host + "/foo/example-app:v0.1.0",
+		executor:  "containerd-shim-spin",
+		replicas:  2,
+		pinDigest: true,
+	})
+	require.Nil(t, err)
+
+	requireManifestsEqual(t, fmt.Sprintf(`apiVersion: core.spinkube.dev/v1alpha1
+kind: SpinApp
+metadata:
+  annotations:
+    spinkube.dev/image-tag: v0.1.0
+  name: example-app
+spec:
+  executor: containerd-shim-spin
+  image: %s/foo/example-app@%s
+  replicas: 2
+`, host, digest), string(output))
+
+	_, err = scaffold(ScaffoldOptions{
+		from:      host + "/foo/example-app:v0.2.0",
+		executor:  "containerd-shim-spin",
+		replicas:  2,
+		pinDigest: true,
+	})
+	require.EqualError(t, err, fmt.Sprintf("failed to pin the digest of %[1]s/foo/example-app:v0.2.0: failed to resolve %[1]s/foo/example-app:v0.2.0: the registry responded with 404 Not Found", host))
+}
+
 func TestValidateImageReference_ValidImageReference(t *testing.T) {
 	testCases := []string{
 		"bacongobbler/hello-rust",
@@ -178,6 +493,19 @@ func TestGetNameFromImageReference(t *testing.T) {
 			reference: "ttl.sh/hello-spinkube@sha256:cc4b191d11728b4e9e024308f0c03aded893da2002403943adc9deb8c4ca1644",
 			name:      "hello-spinkube",
 		},
+		{
+			reference: "ghcr.io/acme/My_App:1.0",
+			name:      "my-app",
+		}, {
+			reference: "localhost:5000/Acme/Hello.World:V1",
+			name:      "hello-world",
+		}, {
+			reference: "ghcr.io/acme/my.app__v2@sha256:cc4b191d11728b4e9e024308f0c03aded893da2002403943adc9deb8c4ca1644",
+			name:      "my-app-v2",
+		}, {
+			reference: "ghcr.io/acme/aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa_bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb:v1",
+			name:      "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa-bbbbbbbbbbbbb-374d0006",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -189,6 +517,31 @@ func TestGetNameFromImageReference(t *testing.T) {
 	}
 }
 
+func TestScaffoldMixedCaseImage(t *testing.T) {
+	var opts ScaffoldOptions
+	flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+	opts.addFlags(flags)
+	require.Nil(t, flags.Parse([]string{"--from", "ghcr.io/acme/My_App:1.0-RC"}))
+
+	output, err := scaffold(opts)
+	require.Nil(t, err)
+	require.Contains(t, string(output), "  name: my-app\n")
+	require.Contains(t, string(output), "  image: ghcr.io/acme/my_app:1.0-RC\n")
+}
+
+func TestDerivedName(t *testing.T) {
+	require.Equal(t, "example-app-runtime-config", derivedName("example-app", "runtime-config"))
+
+	longName := strings.Repeat("a", validation.DNS1123LabelMaxLength)
+	for _, suffix := range []string{"runtime-config", "autoscaler", "interceptor", "trigger-auth"} {
+		name := derivedName(longName, suffix)
+		require.Empty(t, validation.IsDNS1123Label(name))
+		require.True(t, strings.HasSuffix(name, "-"+suffix))
+		require.Equal(t, name, derivedName(longName, suffix))
+		require.NotEqual(t, name, derivedName(longName[1:]+"b", suffix))
+	}
+}
+
 func TestFlagValidation(t *testing.T) {
 	testcases := []struct {
 		name          string
@@ -246,7 +599,7 @@ func TestFlagValidation(t *testing.T) {
 				from:       "ghcr.io/foo/example-app:v0.1.0",
 				autoscaler: "invalid",
 			},
-			expectedError: "invalid autoscaler type 'invalid'; the autoscaler type must be either 'hpa' or 'keda'",
+			expectedError: "invalid autoscaler type 'invalid'; the autoscaler type must be one of 'hpa', 'keda' or 'keda-http'",
 		},
 		{
 			name: "max replica count less than zero",
@@ -305,6 +658,419 @@ func TestFlagValidation(t *testing.T) {
 			},
 			expectedError: "target memory utilization percentage (0) must be between 1 and 100",
 		},
+		{
+			name: "required manifest variable without a value",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				fromManifest: "testdata/spin-v2.toml",
+			},
+			expectedError: "variable 'api_key' is required by the Spin manifest but no value was provided; set it with --variable api_key=<value>",
+		},
+		{
+			name: "variable provided both as a value and from a secret",
+			opts: ScaffoldOptions{
+				from: "ghcr.io/foo/example-app:v0.1.0",
+				variables: map[string]string{
+					"api_key": "s3cr3t",
+				},
+				variablesFromSecret: map[string]string{
+					"api_key": "credentials:api-key",
+				},
+			},
+			expectedError: "variable 'api_key' is provided more than once",
+		},
+		{
+			name: "malformed secret reference",
+			opts: ScaffoldOptions{
+				from: "ghcr.io/foo/example-app:v0.1.0",
+				variablesFromSecret: map[string]string{
+					"api_key": "credentials",
+				},
+			},
+			expectedError: "invalid value 'credentials' for variable 'api_key' from secret; expected name=secretName:key",
+		},
+		{
+			name: "invalid runtime config file",
+			opts: ScaffoldOptions{
+				from:       "ghcr.io/foo/example-app:v0.1.0",
+				configfile: "testdata/runtime-config-invalid.toml",
+			},
+			expectedError: "invalid runtime config file testdata/runtime-config-invalid.toml: key_value_store.default: missing required key 'url' for type 'redis'",
+		},
+		{
+			name: "runtime config validation can be skipped",
+			opts: ScaffoldOptions{
+				from:                        "ghcr.io/foo/example-app:v0.1.0",
+				configfile:                  "testdata/runtime-config-invalid.toml",
+				skipRuntimeConfigValidation: true,
+			},
+		},
+		{
+			name: "runtime config file combined with typed stores",
+			opts: ScaffoldOptions{
+				from:           "ghcr.io/foo/example-app:v0.1.0",
+				configfile:     "testdata/runtime-config.toml",
+				keyValueStores: []string{"default=spin"},
+			},
+			expectedError: "--runtime-config-file cannot be combined with --key-value-store, --sqlite-database or --llm-compute",
+		},
+		{
+			name: "unknown key value store type",
+			opts: ScaffoldOptions{
+				from:           "ghcr.io/foo/example-app:v0.1.0",
+				keyValueStores: []string{"default=redix"},
+			},
+			expectedError: "invalid value 'default=redix' for --key-value-store: unknown type 'redix'; expected one of aws_dynamo, azure_cosmos, redis, spin",
+		},
+		{
+			name: "missing required store option",
+			opts: ScaffoldOptions{
+				from:            "ghcr.io/foo/example-app:v0.1.0",
+				sqliteDatabases: []string{"default=libsql,url=https://example.turso.io"},
+			},
+			expectedError: "invalid value 'default=libsql,url=https://example.turso.io' for --sqlite-database: missing required option 'token' for type 'libsql'",
+		},
+		{
+			name: "malformed store option reference",
+			opts: ScaffoldOptions{
+				from:           "ghcr.io/foo/example-app:v0.1.0",
+				keyValueStores: []string{"default=redis,url=secret:redis-creds"},
+			},
+			expectedError: "invalid value 'default=redis,url=secret:redis-creds' for --key-value-store: invalid option 'url=secret:redis-creds'; expected url=secret:secretName:key",
+		},
+		{
+			name: "duplicate key value store label",
+			opts: ScaffoldOptions{
+				from:           "ghcr.io/foo/example-app:v0.1.0",
+				keyValueStores: []string{"default=spin", "default=redis,url=redis://localhost"},
+			},
+			expectedError: "--key-value-store 'default' is specified more than once",
+		},
+		{
+			name: "negative probe timing",
+			opts: ScaffoldOptions{
+				from:               "ghcr.io/foo/example-app:v0.1.0",
+				livenessPath:       "/healthz",
+				probePeriodSeconds: -1,
+			},
+			expectedError: "--probe-period (-1) must not be negative",
+		},
+		{
+			name: "probe timings without a probe path",
+			opts: ScaffoldOptions{
+				from:               "ghcr.io/foo/example-app:v0.1.0",
+				probePeriodSeconds: 10,
+			},
+			expectedError: "probe timings require --liveness-path or --readiness-path to be set",
+		},
+		{
+			name: "probe timeout greater than period",
+			opts: ScaffoldOptions{
+				from:                "ghcr.io/foo/example-app:v0.1.0",
+				readinessPath:       "/readyz",
+				probePeriodSeconds:  5,
+				probeTimeoutSeconds: 10,
+			},
+			expectedError: "--probe-timeout (10) must not be greater than --probe-period (5)",
+		},
+		{
+			name: "relative probe path",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				livenessPath: "healthz",
+			},
+			expectedError: "--liveness-path 'healthz' must be an absolute path starting with '/'",
+		},
+		{
+			name: "unknown volume type",
+			opts: ScaffoldOptions{
+				from:    "ghcr.io/foo/example-app:v0.1.0",
+				volumes: []string{"data=hostPath:/data"},
+			},
+			expectedError: "invalid volume type 'hostPath' for --volume 'data=hostPath:/data'; expected one of pvc, configmap, secret or emptyDir",
+		},
+		{
+			name: "volume mount without a volume",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				volumeMounts: []string{"data:/data"},
+			},
+			expectedError: "--volume-mount 'data:/data' refers to volume 'data', which is not declared with --volume",
+		},
+		{
+			name: "relative volume mount path",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				volumes:      []string{"data=emptyDir"},
+				volumeMounts: []string{"data:data"},
+			},
+			expectedError: "invalid value 'data:data' for --volume-mount; the mount path must be absolute",
+		},
+		{
+			name: "invalid persistent volume claim size",
+			opts: ScaffoldOptions{
+				from:                   "ghcr.io/foo/example-app:v0.1.0",
+				persistentVolumeClaims: []string{"data=lots"},
+			},
+			expectedError: "invalid size 'lots' for --create-pvc 'data=lots': quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+		{
+			name: "invalid label value",
+			opts: ScaffoldOptions{
+				from: "ghcr.io/foo/example-app:v0.1.0",
+				metadata: metadataOptions{
+					podLabels: map[string]string{"team": "a b"},
+				},
+			},
+			expectedError: "invalid --pod-label value 'a b' for key 'team': a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')",
+		},
+		{
+			name: "KEDA trigger missing required metadata",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                        "keda",
+				replicas:                          1,
+				maxReplicas:                       3,
+				cpuLimit:                          "50m",
+				memoryLimit:                       "100Mi",
+				targetCPUUtilizationPercentage:    50,
+				targetMemoryUtilizationPercentage: 50,
+				kedaTriggers:                      []string{"type=prometheus,serverAddress=http://prometheus:9090,threshold=100"},
+			},
+			expectedError: "invalid value 'type=prometheus,serverAddress=http://prometheus:9090,threshold=100' for --keda-trigger: the prometheus trigger requires the 'query' metadata",
+		},
+		{
+			name: "KEDA trigger metadata provided by trigger authentication",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "keda",
+				replicas:                 0,
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+				kedaTriggers:             []string{"type=rabbitmq,queueName=jobs,mode=QueueLength,value=20"},
+				kedaTriggerAuth:          map[string]string{"host": "rabbitmq-creds:url"},
+			},
+		},
+		{
+			name: "KEDA trigger without a type",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "keda",
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+				kedaTriggers:             []string{"listName=jobs"},
+			},
+			expectedError: "invalid value 'listName=jobs' for --keda-trigger; the scaler type must be set with type=<scaler>",
+		},
+		{
+			name: "KEDA scale to zero without event triggers",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                        "keda",
+				replicas:                          0,
+				maxReplicas:                       3,
+				cpuLimit:                          "50m",
+				memoryLimit:                       "100Mi",
+				targetCPUUtilizationPercentage:    50,
+				targetMemoryUtilizationPercentage: 50,
+			},
+			expectedError: "scaling to zero replicas requires at least one --keda-trigger",
+		},
+		{
+			name: "KEDA without any triggers",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "keda",
+				replicas:                 1,
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+			},
+			expectedError: "at least one --keda-trigger is required when both cpu and memory autoscaling are disabled",
+		},
+		{
+			name: "KEDA triggers without the KEDA autoscaler",
+			opts: ScaffoldOptions{
+				from:         "ghcr.io/foo/example-app:v0.1.0",
+				kedaTriggers: []string{"type=cron,timezone=UTC,start=0 8 * * *,end=0 18 * * *,desiredReplicas=2"},
+			},
+			expectedError: "--keda-trigger and --keda-trigger-auth require --autoscaler keda",
+		},
+		{
+			name: "invalid annotation key",
+			opts: ScaffoldOptions{
+				from: "ghcr.io/foo/example-app:v0.1.0",
+				metadata: metadataOptions{
+					annotations: map[string]string{"-owner": "platform"},
+				},
+			},
+			expectedError: "invalid --annotation key '-owner': name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')",
+		},
+		{
+			name: "invalid name",
+			opts: ScaffoldOptions{
+				from: "ghcr.io/foo/example-app:v0.1.0",
+				name: "Example_App",
+			},
+			expectedError: "invalid --name 'Example_App': a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name: "invalid namespace",
+			opts: ScaffoldOptions{
+				from:      "ghcr.io/foo/example-app:v0.1.0",
+				namespace: "shop.example",
+			},
+			expectedError: "invalid --namespace 'shop.example': must not contain dots",
+		},
+		{
+			name: "malformed cpu limit",
+			opts: ScaffoldOptions{
+				from:     "ghcr.io/foo/example-app:v0.1.0",
+				cpuLimit: "100 millicores",
+			},
+			expectedError: "invalid value '100 millicores' for --cpu-limit: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+		{
+			name: "memory request above the memory limit",
+			opts: ScaffoldOptions{
+				from:          "ghcr.io/foo/example-app:v0.1.0",
+				memoryLimit:   "128Mi",
+				memoryRequest: "1Gi",
+			},
+			expectedError: "--memory-request (1Gi) must be less than or equal to --memory-limit (128Mi)",
+		},
+		{
+			name: "cpu request equal to the cpu limit",
+			opts: ScaffoldOptions{
+				from:       "ghcr.io/foo/example-app:v0.1.0",
+				cpuLimit:   "0.1",
+				cpuRequest: "100m",
+			},
+		},
+		{
+			name: "HPA without cpu and memory metrics",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "hpa",
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+			},
+			expectedError: "at least one --hpa-metric is required when both cpu and memory autoscaling are disabled",
+		},
+		{
+			name: "HPA pods metric with a value target",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "hpa",
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+				hpaMetrics:               []string{"type=pods,name=http_requests_per_second,value=100"},
+			},
+			expectedError: "invalid value 'type=pods,name=http_requests_per_second,value=100' for --hpa-metric; pods metrics only support averageValue",
+		},
+		{
+			name: "HPA object metric without described object",
+			opts: ScaffoldOptions{
+				from:                     "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:               "hpa",
+				maxReplicas:              3,
+				disableCPUAutoscaling:    true,
+				disableMemoryAutoscaling: true,
+				hpaMetrics:               []string{"type=object,name=requests_per_second,value=2k"},
+			},
+			expectedError: "invalid value 'type=object,name=requests_per_second,value=2k' for --hpa-metric; object metrics require describedObject=<apiVersion>/<kind>/<name>",
+		},
+		{
+			name: "HPA scale down policy period too long",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                        "hpa",
+				maxReplicas:                       3,
+				cpuLimit:                          "100m",
+				memoryLimit:                       "128Mi",
+				targetCPUUtilizationPercentage:    60,
+				targetMemoryUtilizationPercentage: 60,
+				hpaScaleDownPolicies:              []string{"pods=1,period=3600"},
+			},
+			expectedError: "invalid value 'pods=1,period=3600' for --hpa-scale-down-policy; the period (3600) must be between 1 and 1800 seconds",
+		},
+		{
+			name: "HPA stabilization window too long",
+			opts: ScaffoldOptions{
+				from:                              "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                        "hpa",
+				maxReplicas:                       3,
+				cpuLimit:                          "100m",
+				memoryLimit:                       "128Mi",
+				targetCPUUtilizationPercentage:    60,
+				targetMemoryUtilizationPercentage: 60,
+				hpaScaleUpStabilizationWindow:     ptr[int32](7200),
+			},
+			expectedError: "--hpa-scale-up-stabilization-window (7200) must be between 0 and 3600 seconds",
+		},
+		{
+			name: "HPA metrics without the HPA autoscaler",
+			opts: ScaffoldOptions{
+				from:       "ghcr.io/foo/example-app:v0.1.0",
+				hpaMetrics: []string{"type=pods,name=http_requests_per_second,averageValue=100"},
+			},
+			expectedError: "--hpa-metric and the --hpa-scale-* flags require --autoscaler hpa",
+		},
+		{
+			name: "disabling cpu autoscaling without an autoscaler",
+			opts: ScaffoldOptions{
+				from:                  "ghcr.io/foo/example-app:v0.1.0",
+				disableCPUAutoscaling: true,
+			},
+			expectedError: "--autoscaler-disable-cpu and --autoscaler-disable-memory require --autoscaler hpa or keda",
+		},
+		{
+			name: "KEDA HTTP autoscaler without hosts",
+			opts: ScaffoldOptions{
+				from:                          "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                    "keda-http",
+				maxReplicas:                   3,
+				kedaHTTPTargetPendingRequests: 100,
+				kedaHTTPInterceptorNamespace:  "keda",
+			},
+			expectedError: "at least one --keda-http-host is required when using the 'keda-http' autoscaler",
+		},
+		{
+			name: "KEDA HTTP path prefix without leading slash",
+			opts: ScaffoldOptions{
+				from:                          "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                    "keda-http",
+				maxReplicas:                   3,
+				kedaHTTPHosts:                 []string{"example.com"},
+				kedaHTTPPathPrefixes:          []string{"api"},
+				kedaHTTPTargetPendingRequests: 100,
+				kedaHTTPInterceptorNamespace:  "keda",
+			},
+			expectedError: "--keda-http-path-prefix 'api' must start with '/'",
+		},
+		{
+			name: "KEDA HTTP target pending requests is 0",
+			opts: ScaffoldOptions{
+				from:                         "ghcr.io/foo/example-app:v0.1.0",
+				autoscaler:                   "keda-http",
+				maxReplicas:                  3,
+				kedaHTTPHosts:                []string{"example.com"},
+				kedaHTTPInterceptorNamespace: "keda",
+			},
+			expectedError: "the target pending requests (0) must be greater than 0",
+		},
+		{
+			name: "KEDA HTTP hosts without the KEDA HTTP autoscaler",
+			opts: ScaffoldOptions{
+				from:          "ghcr.io/foo/example-app:v0.1.0",
+				kedaHTTPHosts: []string{"example.com"},
+			},
+			expectedError: "--keda-http-host and --keda-http-path-prefix require --autoscaler keda-http",
+		},
 	}
 
 	for _, tc := range testcases {
@@ -320,3 +1086,359 @@ func TestFlagValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestFromManifestFlag(t *testing.T) {
+	for _, args := range [][]string{
+		{"--from-manifest", "testdata/spin-v2.toml"},
+		{"--from-manifest=testdata/spin-v2.toml"},
+	} {
+		var opts ScaffoldOptions
+		flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+		opts.addFlags(flags)
+		require.Nil(t, flags.Parse(args))
+		require.Equal(t, "testdata/spin-v2.toml", opts.fromManifest)
+		require.Empty(t, flags.Args())
+	}
+
+	// a directory stands for the spin.toml inside it
+	dir := t.TempDir()
+	content, err := os.ReadFile("testdata/spin-v2.toml")
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(dir, spinManifestFileName), content, 0600))
+
+	manifest, err := loadSpinManifest(dir)
+	require.Nil(t, err)
+	expected, err := loadSpinManifest("testdata/spin-v2.toml")
+	require.Nil(t, err)
+	require.Equal(t, expected, manifest)
+
+	require.EqualError(t, scaffoldCmd.Args(scaffoldCmd, []string{"../app/spin.toml"}), `unknown command "../app/spin.toml" for "kube scaffold"`)
+}
+
+func TestParseSpinManifest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		content       string
+		expected      *spinManifest
+		expectedError string
+	}{
+		{
+			name: "v1 manifest",
+			content: `spin_manifest_version = "1"
+name = "hello"
+[[component]]
+id = "one"
+[[component]]
+id = "two"`,
+			expected: &spinManifest{Name: "hello", Components: []string{"one", "two"}},
+		},
+		{
+			name: "legacy v1 manifest",
+			content: `spin_version = "1"
+name = "hello"`,
+			expected: &spinManifest{Name: "hello"},
+		},
+		{
+			name: "v2 manifest",
+			content: `spin_manifest_version = 2
+[application]
+name = "hello"
+[component.two]
+[component.one]`,
+			expected: &spinManifest{Name: "hello", Components: []string{"one", "two"}},
+		},
+		{
+			name:          "unsupported version",
+			content:       `spin_manifest_version = 3`,
+			expectedError: "unsupported spin_manifest_version '3'",
+		},
+		{
+			name:          "missing version",
+			content:       `name = "hello"`,
+			expectedError: "missing spin_manifest_version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifest, err := parseSpinManifest([]byte(tc.content))
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, manifest)
+		})
+	}
+}
+
+func TestParseSpinManifestName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "v2 manifest",
+			content: `spin_manifest_version = 2
+[application]
+name = "hello"`,
+			expected: "hello",
+		},
+		{
+			name: "v1 manifest with a component without id",
+			content: `spin_manifest_version = "1"
+name = "hello"
+[[component]]
+source = "hello.wasm"`,
+			expected: "hello",
+		},
+		{
+			name: "missing version",
+			content: `[application]
+name = "hello"`,
+			expected: "hello",
+		},
+		{
+			name:    "missing name",
+			content: `spin_manifest_version = 2`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, err := parseSpinManifestName([]byte(tc.content))
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, name)
+		})
+	}
+}
+
+func TestHPAStabilizationWindowFlags(t *testing.T) {
+	opts := ScaffoldOptions{}
+	flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+	opts.addFlags(flags)
+	require.Nil(t, flags.Parse([]string{"--autoscaler", "hpa", "--hpa-scale-up-stabilization-window=0"}))
+
+	behavior, err := newHPABehavior(opts)
+	require.Nil(t, err)
+	require.Equal(t, ptr[int32](0), behavior.ScaleUp.StabilizationWindowSeconds)
+	require.Nil(t, behavior.ScaleDown)
+	require.Equal(t, "0", flags.Lookup("hpa-scale-up-stabilization-window").Value.String())
+	require.Equal(t, "", flags.Lookup("hpa-scale-down-stabilization-window").Value.String())
+}
+
+func TestInterceptorService(t *testing.T) {
+	var output strings.Builder
+	require.Nil(t, printObjects(&output, newInterceptorService(appConfig{Name: "example-app", KedaHTTPInterceptorNamespace: "keda-system"})))
+	require.Equal(t, `apiVersion: v1
+kind: Service
+metadata:
+  name: example-app-interceptor
+spec:
+  externalName: keda-add-ons-http-interceptor-proxy.keda-system.svc.cluster.local
+  ports:
+  - name: http
+    port: 8080
+  type: ExternalName
+`, output.String())
+}
+
+func TestSplitKeyValuePairs(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "unquoted",
+			value:    "type=redis,listName=jobs",
+			expected: []string{"type=redis", "listName=jobs"},
+		},
+		{
+			name:     "quoted value with commas",
+			value:    `type=kafka,bootstrapServers="a:9092,b:9092",topic=orders`,
+			expected: []string{"type=kafka", "bootstrapServers=a:9092,b:9092", "topic=orders"},
+		},
+		{
+			name:     "quoted pair",
+			value:    `"topic=orders,payments",type=kafka`,
+			expected: []string{"topic=orders,payments", "type=kafka"},
+		},
+		{
+			name:     "escaped quote in quoted value",
+			value:    `query="sum(rate(http_requests_total{job=""api""}[2m])) by (pod,method)"`,
+			expected: []string{`query=sum(rate(http_requests_total{job="api"}[2m])) by (pod,method)`},
+		},
+		{
+			name:     "quotes inside a value are kept",
+			value:    `query=sum(rate(http_requests_total{job="api"}[2m]))`,
+			expected: []string{`query=sum(rate(http_requests_total{job="api"}[2m]))`},
+		},
+		{
+			name:          "unterminated quote",
+			value:         `bootstrapServers="a:9092,b:9092`,
+			expectedError: "unterminated quoted value in 'bootstrapServers='",
+		},
+		{
+			name:          "text after the closing quote",
+			value:         `bootstrapServers="a:9092"x`,
+			expectedError: "unexpected character after the quoted value in 'bootstrapServers=a:9092'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pairs, err := splitKeyValuePairs(tc.value)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, pairs)
+		})
+	}
+}
+
+func TestParseKedaTriggerQuotedMetadata(t *testing.T) {
+	trigger, err := parseKedaTrigger(`type=kafka,bootstrapServers="kafka-0:9092,kafka-1:9092",consumerGroup=workers,topic=orders`)
+	require.Nil(t, err)
+	require.Equal(t, "kafka", trigger.Type)
+	require.Equal(t, map[string]string{
+		"bootstrapServers": "kafka-0:9092,kafka-1:9092",
+		"consumerGroup":    "workers",
+		"topic":            "orders",
+	}, trigger.Metadata)
+
+	_, err = parseKedaTrigger("type=kafka,bootstrapServers=kafka-0:9092,kafka-1:9092,consumerGroup=workers")
+	require.EqualError(t, err, `invalid value 'type=kafka,bootstrapServers=kafka-0:9092,kafka-1:9092,consumerGroup=workers' for --keda-trigger; expected type=<scaler>,key=value... but got 'kafka-1:9092' (enclose values that contain commas in double quotes, e.g. key="a,b")`)
+}
+
+func TestNewPersistentVolumeClaim(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		value                string
+		expectedStorageClass *string
+		expectedAccessMode   corev1.PersistentVolumeAccessMode
+		expectedError        string
+	}{
+		{
+			name:               "defaults",
+			value:              "data=1Gi",
+			expectedAccessMode: corev1.ReadWriteOnce,
+		},
+		{
+			name:                 "storage class",
+			value:                "data=1Gi,standard",
+			expectedStorageClass: ptr("standard"),
+			expectedAccessMode:   corev1.ReadWriteOnce,
+		},
+		{
+			name:               "access mode",
+			value:              "data=1Gi,ReadWriteMany",
+			expectedAccessMode: corev1.ReadWriteMany,
+		},
+		{
+			name:                 "storage class and access mode",
+			value:                "data=1Gi,nfs,ReadWriteMany",
+			expectedStorageClass: ptr("nfs"),
+			expectedAccessMode:   corev1.ReadWriteMany,
+		},
+		{
+			name:          "invalid access mode",
+			value:         "data=1Gi,nfs,RWX",
+			expectedError: "invalid access mode 'RWX' for --create-pvc 'data=1Gi,nfs,RWX'; expected one of ReadWriteOnce, ReadWriteMany, ReadOnlyMany or ReadWriteOncePod",
+		},
+		{
+			name:          "too many fields",
+			value:         "data=1Gi,nfs,ReadWriteMany,extra",
+			expectedError: "invalid value 'data=1Gi,nfs,ReadWriteMany,extra' for --create-pvc; expected name=size[,storageClass][,accessMode]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pvc, err := newPersistentVolumeClaim(tc.value)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedStorageClass, pvc.Spec.StorageClassName)
+			require.Equal(t, []corev1.PersistentVolumeAccessMode{tc.expectedAccessMode}, pvc.Spec.AccessModes)
+		})
+	}
+}
+
+func TestSingleNodeClaimWarnings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     ScaffoldOptions
+		expected []string
+	}{
+		{
+			name: "single replica",
+			opts: ScaffoldOptions{
+				replicas:               1,
+				volumes:                []string{"data=pvc:example-app-data"},
+				persistentVolumeClaims: []string{"example-app-data=1Gi"},
+			},
+		},
+		{
+			name: "multiple replicas with a ReadWriteOnce claim",
+			opts: ScaffoldOptions{
+				replicas:               2,
+				volumes:                []string{"data=pvc:example-app-data"},
+				persistentVolumeClaims: []string{"example-app-data=1Gi"},
+			},
+			expected: []string{"the ReadWriteOnce claim example-app-data is mounted by an application that can run more than one replica; replicas on other nodes may fail to start, consider example-app-data=<size>,ReadWriteMany with a storage class that supports it or --replicas 1"},
+		},
+		{
+			name: "autoscaler with a ReadWriteOncePod claim",
+			opts: ScaffoldOptions{
+				replicas:               1,
+				autoscaler:             "hpa",
+				volumes:                []string{"data=pvc:example-app-data"},
+				persistentVolumeClaims: []string{"example-app-data=1Gi,ReadWriteOncePod"},
+			},
+			expected: []string{"the ReadWriteOncePod claim example-app-data is mounted by an application that can run more than one replica; replicas on other nodes may fail to start, consider example-app-data=<size>,ReadWriteMany with a storage class that supports it or --replicas 1"},
+		},
+		{
+			name: "multiple replicas with a ReadWriteMany claim",
+			opts: ScaffoldOptions{
+				replicas:               2,
+				volumes:                []string{"data=pvc:example-app-data"},
+				persistentVolumeClaims: []string{"example-app-data=1Gi,nfs,ReadWriteMany"},
+			},
+		},
+		{
+			name: "claim not mounted by the application",
+			opts: ScaffoldOptions{
+				replicas:               2,
+				persistentVolumeClaims: []string{"example-app-data=1Gi"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := appConfig{Replicas: tc.opts.replicas, Autoscaler: tc.opts.autoscaler}
+			for _, value := range tc.opts.volumes {
+				volume, err := parseVolume(value)
+				require.Nil(t, err)
+				config.Volumes = append(config.Volumes, volume)
+			}
+			for _, value := range tc.opts.persistentVolumeClaims {
+				pvc, err := newPersistentVolumeClaim(value)
+				require.Nil(t, err)
+				config.PersistentVolumeClaims = append(config.PersistentVolumeClaims, pvc)
+			}
+
+			require.Equal(t, tc.expected, singleNodeClaimWarnings(config))
+		})
+	}
+}
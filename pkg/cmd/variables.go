@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// resolveVariables collects the application variables from the variables file, the literal --variable flags, the
+// Secret and ConfigMap references and, if given, the defaults declared in the Spin manifest. Literal flags override
+// values from the variables file; any other variable provided by more than one source is an error.
+func resolveVariables(opts ScaffoldOptions, manifest *spinManifest) ([]spinv1alpha1.SpinVar, error) {
+	variables := map[string]spinv1alpha1.SpinVar{}
+
+	if opts.variablesFile != "" {
+		values, err := loadVariablesFile(opts.variablesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range values {
+			variables[name] = spinv1alpha1.SpinVar{Name: name, Value: value}
+		}
+	}
+
+	for name, value := range opts.variables {
+		variables[name] = spinv1alpha1.SpinVar{Name: name, Value: value}
+	}
+
+	for _, name := range sortedKeys(opts.variablesFromSecret) {
+		secretName, key, err := parseKeyReference(opts.variablesFromSecret[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for variable '%s' from secret; expected name=secretName:key", opts.variablesFromSecret[name], name)
+		}
+
+		if _, ok := variables[name]; ok {
+			return nil, fmt.Errorf("variable '%s' is provided more than once", name)
+		}
+
+		variables[name] = spinv1alpha1.SpinVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	for _, name := range sortedKeys(opts.variablesFromConfigMap) {
+		configMapName, key, err := parseKeyReference(opts.variablesFromConfigMap[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value '%s' for variable '%s' from configmap; expected name=configMapName:key", opts.variablesFromConfigMap[name], name)
+		}
+
+		if _, ok := variables[name]; ok {
+			return nil, fmt.Errorf("variable '%s' is provided more than once", name)
+		}
+
+		variables[name] = spinv1alpha1.SpinVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+					Key:                  key,
+				},
+			},
+		}
+	}
+
+	if manifest != nil {
+		provided := map[string]bool{}
+		for name := range variables {
+			provided[name] = true
+		}
+
+		defaults, err := manifest.defaultVariables(provided)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range defaults {
+			variables[name] = spinv1alpha1.SpinVar{Name: name, Value: value}
+		}
+	}
+
+	result := make([]spinv1alpha1.SpinVar, 0, len(variables))
+	for _, name := range sortedKeys(variables) {
+		result = append(result, variables[name])
+	}
+
+	return result, nil
+}
+
+// parseKeyReference parses a reference of the form `objectName:key`.
+func parseKeyReference(ref string) (string, string, error) {
+	name, key, found := strings.Cut(ref, ":")
+	if !found || name == "" || key == "" {
+		return "", "", fmt.Errorf("invalid reference '%s'", ref)
+	}
+
+	return name, key, nil
+}
+
+// splitKeyValuePairs splits a comma-separated list of key=value pairs. Values that contain commas must be enclosed in
+// double quotes, e.g. `bootstrapServers="a:9092,b:9092"`, and a double quote inside a quoted value is written as "".
+// Double quotes that do not start a value are kept as is, so that values such as `job="api"` need no quoting.
+func splitKeyValuePairs(value string) ([]string, error) {
+	var (
+		pairs []string
+		pair  strings.Builder
+	)
+
+	for i := 0; i < len(value); i++ {
+		current := pair.String()
+		startsValue := current == "" || (strings.HasSuffix(current, "=") && strings.Count(current, "=") == 1)
+
+		switch {
+		case value[i] == ',':
+			pairs = append(pairs, current)
+			pair.Reset()
+		case value[i] == '"' && startsValue:
+			closed := false
+			for i++; i < len(value); i++ {
+				if value[i] != '"' {
+					pair.WriteByte(value[i])
+				} else if i+1 < len(value) && value[i+1] == '"' {
+					pair.WriteByte('"')
+					i++
+				} else {
+					closed = true
+					break
+				}
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value in '%s'", current)
+			}
+
+			if i+1 < len(value) && value[i+1] != ',' {
+				return nil, fmt.Errorf("unexpected character after the quoted value in '%s'", pair.String())
+			}
+		default:
+			pair.WriteByte(value[i])
+		}
+	}
+
+	return append(pairs, pair.String()), nil
+}
+
+// loadVariablesFile reads variables from a YAML file containing a flat map of names to values, or from a dotenv file
+// with one NAME=value pair per line.
+func loadVariablesFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := filepath.Ext(path); {
+	case ext == ".yaml" || ext == ".yml":
+		values := map[string]string{}
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse variables file %s: %w", path, err)
+		}
+
+		return values, nil
+	case ext == ".env" || filepath.Base(path) == ".env":
+		values, err := parseDotEnv(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse variables file %s: %w", path, err)
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported variables file '%s'; expected a .yaml, .yml or .env file", path)
+	}
+}
+
+func parseDotEnv(content []byte) (map[string]string, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("line %d: expected NAME=value", lineNumber)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[name] = value
+	}
+
+	return values, scanner.Err()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parseVolume parses a --volume flag of the form `name=pvc:claimName`, `name=configmap:configMapName`,
+// `name=secret:secretName` or `name=emptyDir`.
+func parseVolume(value string) (corev1.Volume, error) {
+	name, source, found := strings.Cut(value, "=")
+	if !found || name == "" || source == "" {
+		return corev1.Volume{}, fmt.Errorf("invalid value '%s' for --volume; expected name=pvc:claimName, name=configmap:configMapName, name=secret:secretName or name=emptyDir", value)
+	}
+
+	volume := corev1.Volume{Name: name}
+
+	if source == "emptyDir" {
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		return volume, nil
+	}
+
+	sourceType, sourceName, found := strings.Cut(source, ":")
+	if !found || sourceName == "" {
+		return corev1.Volume{}, fmt.Errorf("invalid value '%s' for --volume; expected name=pvc:claimName, name=configmap:configMapName, name=secret:secretName or name=emptyDir", value)
+	}
+
+	switch sourceType {
+	case "pvc":
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sourceName}
+	case "configmap":
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: sourceName},
+		}
+	case "secret":
+		volume.Secret = &corev1.SecretVolumeSource{SecretName: sourceName}
+	default:
+		return corev1.Volume{}, fmt.Errorf("invalid volume type '%s' for --volume '%s'; expected one of pvc, configmap, secret or emptyDir", sourceType, value)
+	}
+
+	return volume, nil
+}
+
+// parseVolumeMount parses a --volume-mount flag of the form `name:/path[:ro]`.
+func parseVolumeMount(value string) (corev1.VolumeMount, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return corev1.VolumeMount{}, fmt.Errorf("invalid value '%s' for --volume-mount; expected name:/path[:ro]", value)
+	}
+
+	if !path.IsAbs(parts[1]) {
+		return corev1.VolumeMount{}, fmt.Errorf("invalid value '%s' for --volume-mount; the mount path must be absolute", value)
+	}
+
+	mount := corev1.VolumeMount{
+		Name:      parts[0],
+		MountPath: parts[1],
+	}
+
+	if len(parts) == 3 {
+		if parts[2] != "ro" {
+			return corev1.VolumeMount{}, fmt.Errorf("invalid value '%s' for --volume-mount; expected name:/path[:ro]", value)
+		}
+
+		mount.ReadOnly = true
+	}
+
+	return mount, nil
+}
+
+// persistentVolumeAccessModes lists the access modes accepted by --create-pvc.
+var persistentVolumeAccessModes = []corev1.PersistentVolumeAccessMode{
+	corev1.ReadWriteOnce,
+	corev1.ReadWriteMany,
+	corev1.ReadOnlyMany,
+	corev1.ReadWriteOncePod,
+}
+
+// newPersistentVolumeClaim parses a --create-pvc flag of the form `name=size[,storageClass][,accessMode]` into a
+// PersistentVolumeClaim. The access mode defaults to ReadWriteOnce.
+func newPersistentVolumeClaim(value string) (*corev1.PersistentVolumeClaim, error) {
+	name, spec, found := strings.Cut(value, "=")
+	fields := strings.Split(spec, ",")
+	size := fields[0]
+	if !found || name == "" || size == "" || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid value '%s' for --create-pvc; expected name=size[,storageClass][,accessMode]", value)
+	}
+
+	var storageClass string
+	accessMode := corev1.ReadWriteOnce
+	switch {
+	case len(fields) == 3:
+		storageClass, accessMode = fields[1], corev1.PersistentVolumeAccessMode(fields[2])
+	case len(fields) == 2 && slices.Contains(persistentVolumeAccessModes, corev1.PersistentVolumeAccessMode(fields[1])):
+		accessMode = corev1.PersistentVolumeAccessMode(fields[1])
+	case len(fields) == 2:
+		storageClass = fields[1]
+	}
+
+	if !slices.Contains(persistentVolumeAccessModes, accessMode) {
+		return nil, fmt.Errorf("invalid access mode '%s' for --create-pvc '%s'; expected one of ReadWriteOnce, ReadWriteMany, ReadOnlyMany or ReadWriteOncePod", accessMode, value)
+	}
+
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size '%s' for --create-pvc '%s': %w", size, value, err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "PersistentVolumeClaim",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{accessMode},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: quantity,
+				},
+			},
+		},
+	}
+
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+
+	return pvc, nil
+}
+
+// singleNodeClaimWarnings returns a warning for each ReadWriteOnce or ReadWriteOncePod claim generated with --create-pvc
+// and mounted by the application when it can run more than one replica. Such claims can only be mounted by the pods of
+// a single node, or by a single pod, so additional replicas may fail to start.
+func singleNodeClaimWarnings(config appConfig) []string {
+	if config.Replicas <= 1 && config.Autoscaler == "" {
+		return nil
+	}
+
+	var warnings []string
+	for _, pvc := range config.PersistentVolumeClaims {
+		accessMode := pvc.Spec.AccessModes[0]
+		if accessMode != corev1.ReadWriteOnce && accessMode != corev1.ReadWriteOncePod {
+			continue
+		}
+
+		mounted := slices.ContainsFunc(config.Volumes, func(v corev1.Volume) bool {
+			return v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == pvc.Name
+		})
+		if mounted {
+			warnings = append(warnings, fmt.Sprintf("the %s claim %s is mounted by an application that can run more than one replica; replicas on other nodes may fail to start, consider %s=<size>,ReadWriteMany with a storage class that supports it or --replicas 1", accessMode, pvc.Name, pvc.Name))
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spinkube/spin-plugin-kube/pkg/prompt"
+)
+
+// autoscalerChoices are the autoscalers offered by the wizard. "none" leaves --autoscaler unset.
+var autoscalerChoices = []string{"none", "hpa", "keda", "keda-http"}
+
+// shellSafeValue matches flag values that do not need to be quoted in a shell.
+var shellSafeValue = regexp.MustCompile(`^[A-Za-z0-9_./:=@,%+-]*$`)
+
+// scaffoldWizard asks for the scaffold options that matter most to new users. Every answer is validated together with
+// the previous answers and the flags that are already set, with the same rules as the flags.
+type scaffoldWizard struct {
+	prompter    *prompt.Prompter
+	base        ScaffoldOptions
+	baseInvalid bool
+	args        []string
+}
+
+// runScaffoldWizard asks for the options on top of base and returns the resulting options, together with the names of
+// the flags the answers changed.
+func runScaffoldWizard(p *prompt.Prompter, base ScaffoldOptions) (ScaffoldOptions, []string, error) {
+	w := &scaffoldWizard{prompter: p, base: base}
+
+	if err := w.run(); err != nil {
+		return ScaffoldOptions{}, nil, err
+	}
+
+	opts, err := w.options()
+	if err != nil {
+		return ScaffoldOptions{}, nil, err
+	}
+
+	if err := validateFlags(opts); err != nil {
+		return ScaffoldOptions{}, nil, err
+	}
+
+	var answered []string
+	for _, arg := range w.args {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !slices.Contains(answered, name) {
+			answered = append(answered, name)
+		}
+	}
+
+	return opts, answered, nil
+}
+
+func (w *scaffoldWizard) run() error {
+	// the image is checked on its own, since the options cannot be valid without it
+	from, err := w.prompter.Text("Image reference", w.base.from, func(answer string) error {
+		if _, err := w.options(flagArgs("from", answer, w.base.from)...); err != nil {
+			return err
+		}
+
+		return checkImageReference(answer)
+	})
+	if err != nil {
+		return err
+	}
+	w.args = append(w.args, flagArgs("from", from, w.base.from)...)
+
+	if previous, err := w.options(); err != nil || validateFlags(previous) != nil {
+		w.baseInvalid = true
+	}
+
+	if err := w.askResource("cpu", "CPU", "100m", w.base.cpuRequest, w.base.cpuLimit); err != nil {
+		return err
+	}
+
+	if err := w.askResource("memory", "Memory", "128Mi", w.base.memoryRequest, w.base.memoryLimit); err != nil {
+		return err
+	}
+
+	current := cmp.Or(w.base.autoscaler, "none")
+	autoscaler, err := w.prompter.Select("Autoscaler", autoscalerChoices, current, func(choice string) error {
+		// the hosts the KEDA HTTP add-on routes are asked for next
+		if choice == "keda-http" {
+			return nil
+		}
+
+		return w.validate(autoscalerArgs(choice, current)...)
+	})
+	if err != nil {
+		return err
+	}
+	w.args = append(w.args, autoscalerArgs(autoscaler, current)...)
+
+	if autoscaler == "keda-http" {
+		if _, err := w.ask("keda-http-host", "Hosts routed to the application (comma-separated)", strings.Join(w.base.kedaHTTPHosts, ",")); err != nil {
+			return err
+		}
+	}
+
+	if autoscaler == "none" {
+		if _, err := w.ask("replicas", "Replicas", strconv.Itoa(int(w.base.replicas))); err != nil {
+			return err
+		}
+	} else {
+		if _, err := w.ask("replicas", "Minimum replicas", strconv.Itoa(int(w.base.replicas))); err != nil {
+			return err
+		}
+
+		if _, err := w.ask("max-replicas", "Maximum replicas", strconv.Itoa(int(w.base.maxReplicas))); err != nil {
+			return err
+		}
+	}
+
+	if autoscaler == "hpa" || autoscaler == "keda" {
+		if _, err := w.ask("autoscaler-target-cpu-utilization", "Target CPU utilization (%)", strconv.Itoa(int(w.base.targetCPUUtilizationPercentage))); err != nil {
+			return err
+		}
+
+		if _, err := w.ask("autoscaler-target-memory-utilization", "Target memory utilization (%)", strconv.Itoa(int(w.base.targetMemoryUtilizationPercentage))); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.ask("variable", "Variables as name=value (comma-separated, empty for none)", ""); err != nil {
+		return err
+	}
+
+	if _, err := w.ask("image-pull-secret", "Image pull secrets (comma-separated, empty for none)", strings.Join(w.base.imagePullSecrets, ",")); err != nil {
+		return err
+	}
+
+	configfile, err := w.prompter.Text("Runtime config file (empty for none)", w.base.configfile, func(answer string) error {
+		if err := w.validate(flagArgs("runtime-config-file", answer, w.base.configfile)...); err != nil {
+			return err
+		}
+
+		opts, err := w.options(flagArgs("runtime-config-file", answer, w.base.configfile)...)
+		if err != nil {
+			return err
+		}
+
+		_, err = loadRuntimeConfig(opts)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	w.args = append(w.args, flagArgs("runtime-config-file", configfile, w.base.configfile)...)
+
+	return nil
+}
+
+// options returns the base options with the answers and the extra flags applied.
+func (w *scaffoldWizard) options(extra ...string) (ScaffoldOptions, error) {
+	return applyFlagOverrides(w.base, append(slices.Clone(w.args), extra...))
+}
+
+// validate checks the options that result from applying the extra flags on top of the previous answers. When the
+// flags that are already set conflict, e.g. --autoscaler hpa without the limits that are asked later, the answers
+// cannot be blamed for it until they resolve the conflict: until then, they are only checked on their own, and the
+// options are validated again once all questions are answered.
+func (w *scaffoldWizard) validate(extra ...string) error {
+	opts, err := w.options(extra...)
+	if err != nil {
+		return err
+	}
+
+	if err := validateFlags(opts); err == nil {
+		return nil
+	} else if previous, _ := w.options(); !w.baseInvalid || validateFlags(previous) == nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkImageReference checks the image reference with the same rule as --from.
+func checkImageReference(from string) error {
+	if !validateImageReference(lowercaseRepository(from)) {
+		return fmt.Errorf("invalid image reference provided: '%s'", from)
+	}
+
+	return nil
+}
+
+// ask asks for the value of a flag, with its current value as the default.
+func (w *scaffoldWizard) ask(flag, question, current string) (string, error) {
+	answer, err := w.prompter.Text(question, current, func(answer string) error {
+		return w.validate(flagArgs(flag, answer, current)...)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	w.args = append(w.args, flagArgs(flag, answer, current)...)
+	return answer, nil
+}
+
+// askResource asks for the request and the limit of a resource. They are validated as a pair, and both are asked again
+// when the pair is rejected, so that a request that exceeds the limit can be fixed on either side.
+func (w *scaffoldWizard) askResource(name, title, example, currentRequest, currentLimit string) error {
+	requestFlag, limitFlag := name+"-request", name+"-limit"
+	for {
+		request, err := w.prompter.Text(fmt.Sprintf("%s request, e.g. %s (empty for none)", title, example), currentRequest, func(answer string) error {
+			_, err := parseQuantityFlag(requestFlag, answer)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		limit, err := w.prompter.Text(fmt.Sprintf("%s limit, e.g. %s (empty for none)", title, example), currentLimit, func(answer string) error {
+			_, err := parseQuantityFlag(limitFlag, answer)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		args := append(flagArgs(requestFlag, request, currentRequest), flagArgs(limitFlag, limit, currentLimit)...)
+		if err := w.validate(args...); err != nil {
+			w.prompter.Error(err)
+			continue
+		}
+
+		w.args = append(w.args, args...)
+		return nil
+	}
+}
+
+// flagArgs returns the argument that sets the flag to the answer, or no argument if the answer keeps the current value.
+func flagArgs(flag, answer, current string) []string {
+	if answer == current {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("--%s=%s", flag, answer)}
+}
+
+// autoscalerArgs returns the argument that selects the autoscaler choice, where "none" unsets --autoscaler.
+func autoscalerArgs(choice, current string) []string {
+	if choice == current {
+		return nil
+	}
+
+	if choice == "none" {
+		return []string{"--autoscaler="}
+	}
+
+	return []string{"--autoscaler=" + choice}
+}
+
+// equivalentCommand returns the non-interactive scaffold command that produces the same output as the options. Flags
+// with their default value are left out, unless they are explicit: a flag that was set on the command line or by an
+// answer must be kept, since leaving it out would let the project config set it to a different value.
+func equivalentCommand(opts ScaffoldOptions, explicit ...string) string {
+	var bound ScaffoldOptions
+	flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+	bound.addFlags(flags)
+
+	// the flags are bound to the fields of bound, which registering them has reset to the defaults; copying the
+	// options into bound makes the flags report the values of the options
+	bound = opts
+
+	args := []string{"spin", "kube", "scaffold"}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Value.String() == f.DefValue && !slices.Contains(explicit, f.Name) {
+			return
+		}
+
+		switch f.Value.Type() {
+		case "bool":
+			if f.Value.String() == "true" {
+				args = append(args, "--"+f.Name)
+			} else {
+				args = append(args, "--"+f.Name+"=false")
+			}
+		case "stringToString":
+			values, _ := flags.GetStringToString(f.Name)
+			for _, key := range sortedKeys(values) {
+				args = append(args, "--"+f.Name, shellQuote(key+"="+values[key]))
+			}
+		case "stringSlice", "stringArray":
+			values, _ := f.Value.(pflag.SliceValue)
+			for _, value := range values.GetSlice() {
+				args = append(args, "--"+f.Name, shellQuote(value))
+			}
+		default:
+			args = append(args, "--"+f.Name, shellQuote(f.Value.String()))
+		}
+	})
+
+	for _, flag := range []struct{ name, value string }{
+		{"namespace", opts.namespace},
+		{"out", opts.output},
+		{"helm-chart", opts.helmChart},
+		{"kustomize", opts.kustomize},
+	} {
+		if flag.value != "" {
+			args = append(args, "--"+flag.name, shellQuote(flag.value))
+		}
+	}
+
+	return strings.Join(args, " ")
+}
+
+func shellQuote(value string) string {
+	if value != "" && shellSafeValue.MatchString(value) {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
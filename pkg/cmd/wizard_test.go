@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spinkube/spin-plugin-kube/pkg/prompt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaffoldWizard(t *testing.T) {
+	testcases := []struct {
+		name            string
+		answers         []string
+		expectedCommand string
+		expectedOutput  []string
+	}{
+		{
+			name: "defaults",
+			answers: []string{
+				"ghcr.io/foo/example-app:v0.1.0", // image
+				"",                               // cpu request
+				"",                               // cpu limit
+				"",                               // memory request
+				"",                               // memory limit
+				"",                               // autoscaler
+				"",                               // replicas
+				"",                               // variables
+				"",                               // image pull secrets
+				"",                               // runtime config file
+			},
+			expectedCommand: "spin kube scaffold --from ghcr.io/foo/example-app:v0.1.0",
+		},
+		{
+			name: "invalid answers are asked again",
+			answers: []string{
+				"ghcr.io/foo/example app",        // image
+				"ghcr.io/foo/example-app:v0.1.0", // image
+				"200m",                           // cpu request
+				"lots",                           // cpu limit
+				"100m",                           // cpu limit
+				"50m",                            // cpu request
+				"100m",                           // cpu limit
+				"",                               // memory request
+				"",                               // memory limit
+				"hpa",                            // autoscaler
+				"1",                              // autoscaler
+				"-1",                             // replicas
+				"3",                              // replicas
+				"greeting",                       // variables
+				"greeting=hello,farewell=bye",    // variables
+				"registry-credentials",           // image pull secrets
+				"testdata/missing.toml",          // runtime config file
+				"testdata/runtime-config.toml",   // runtime config file
+			},
+			expectedCommand: "spin kube scaffold --cpu-limit 100m --cpu-request 50m --from ghcr.io/foo/example-app:v0.1.0 --image-pull-secret registry-credentials --replicas 3 --runtime-config-file testdata/runtime-config.toml --variable farewell=bye --variable greeting=hello",
+			expectedOutput: []string{
+				"Error: invalid image reference provided: 'ghcr.io/foo/example app'",
+				"Error: invalid value 'lots' for --cpu-limit: quantities must match the regular expression",
+				"Error: --cpu-request (200m) must be less than or equal to --cpu-limit (100m)",
+				"Error: memory limits must be set when autoscaling is enabled",
+				"Error: the minimum replica count (-1) must be greater than 0",
+				`Error: invalid argument "greeting" for "-v, --variable" flag: greeting must be formatted as key=value`,
+				"Error: open testdata/missing.toml: no such file or directory",
+			},
+		},
+		{
+			name: "KEDA HTTP autoscaler",
+			answers: []string{
+				"ghcr.io/foo/example-app:v0.1.0", // image
+				"",                               // cpu request
+				"",                               // cpu limit
+				"",                               // memory request
+				"",                               // memory limit
+				"keda-http",                      // autoscaler
+				"",                               // hosts
+				"example.com",                    // hosts
+				"0",                              // minimum replicas
+				"10",                             // maximum replicas
+				"",                               // variables
+				"",                               // image pull secrets
+				"",                               // runtime config file
+			},
+			expectedCommand: "spin kube scaffold --autoscaler keda-http --from ghcr.io/foo/example-app:v0.1.0 --keda-http-host example.com --max-replicas 10 --replicas 0",
+			expectedOutput: []string{
+				"Error: at least one --keda-http-host is required when using the 'keda-http' autoscaler",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var base ScaffoldOptions
+			base.addFlags(pflag.NewFlagSet("scaffold", pflag.ContinueOnError))
+
+			var output strings.Builder
+			p := prompt.New(strings.NewReader(strings.Join(tc.answers, "\n")+"\n"), &output)
+
+			opts, _, err := runScaffoldWizard(p, base)
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedCommand, equivalentCommand(opts))
+
+			errors := 0
+			for _, line := range strings.Split(output.String(), "\n") {
+				if strings.Contains(line, "Error: ") {
+					errors++
+				}
+			}
+			require.Equal(t, len(tc.expectedOutput), errors, output.String())
+
+			for _, expected := range tc.expectedOutput {
+				require.Contains(t, output.String(), expected)
+			}
+
+			// the resulting options are complete and valid
+			_, err = scaffold(opts)
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestScaffoldWizardConflictingFlags(t *testing.T) {
+	testcases := []struct {
+		name            string
+		answers         []string
+		expectedCommand string
+		expectedError   string
+	}{
+		{
+			name: "answers resolve the conflict",
+			answers: []string{
+				"ghcr.io/foo/example app",        // image
+				"ghcr.io/foo/example-app:v0.1.0", // image
+				"",                               // cpu request
+				"100m",                           // cpu limit
+				"",                               // memory request
+				"128Mi",                          // memory limit
+				"",                               // autoscaler
+				"",                               // minimum replicas
+				"",                               // maximum replicas
+				"",                               // target cpu utilization
+				"",                               // target memory utilization
+				"",                               // variables
+				"",                               // image pull secrets
+				"",                               // runtime config file
+			},
+			expectedCommand: "spin kube scaffold --autoscaler hpa --cpu-limit 100m --from ghcr.io/foo/example-app:v0.1.0 --memory-limit 128Mi",
+		},
+		{
+			name: "answers leave the conflict",
+			answers: []string{
+				"ghcr.io/foo/example-app:v0.1.0", // image
+				"",                               // cpu request
+				"",                               // cpu limit
+				"",                               // memory request
+				"",                               // memory limit
+				"",                               // autoscaler
+				"",                               // minimum replicas
+				"",                               // maximum replicas
+				"",                               // target cpu utilization
+				"",                               // target memory utilization
+				"",                               // variables
+				"",                               // image pull secrets
+				"",                               // runtime config file
+			},
+			expectedError: "cpu limits must be set when autoscaling is enabled",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// --autoscaler hpa is set without the limits it requires, which are only asked after the image
+			var base ScaffoldOptions
+			base.addFlags(pflag.NewFlagSet("scaffold", pflag.ContinueOnError))
+			base.autoscaler = "hpa"
+
+			var output strings.Builder
+			p := prompt.New(strings.NewReader(strings.Join(tc.answers, "\n")+"\n"), &output)
+
+			opts, _, err := runScaffoldWizard(p, base)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				require.NotContains(t, output.String(), "Error: ")
+				return
+			}
+
+			require.Nil(t, err, output.String())
+			require.Equal(t, tc.expectedCommand, equivalentCommand(opts))
+			require.Equal(t, 1, strings.Count(output.String(), "Error: "), output.String())
+			require.Contains(t, output.String(), "Error: invalid image reference provided: 'ghcr.io/foo/example app'")
+		})
+	}
+}
+
+func TestEquivalentCommand(t *testing.T) {
+	var opts ScaffoldOptions
+	opts.addFlags(pflag.NewFlagSet("scaffold", pflag.ContinueOnError))
+	opts.from = "ghcr.io/foo/example-app:v0.1.0"
+	opts.variables = map[string]string{"greeting": "hello world"}
+	opts.pinDigest = true
+	opts.namespace = "shop"
+
+	require.Equal(t, "spin kube scaffold --from ghcr.io/foo/example-app:v0.1.0 --pin-digest --variable 'greeting=hello world' --namespace shop", equivalentCommand(opts))
+}
+
+// parseCommand splits the command into its arguments, undoing the quoting of shellQuote.
+func parseCommand(t *testing.T, command string) []string {
+	var args []string
+	var arg strings.Builder
+	quoted, escaped, started := false, false, false
+	for _, r := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && !quoted:
+			escaped = true
+			started = true
+		case r == '\'':
+			quoted = !quoted
+			started = true
+		case r == ' ' && !quoted:
+			if started {
+				args = append(args, arg.String())
+			}
+			arg.Reset()
+			started = false
+		default:
+			arg.WriteRune(r)
+			started = true
+		}
+	}
+	require.False(t, quoted, command)
+
+	return append(args, arg.String())
+}
+
+func TestEquivalentCommandRoundTrip(t *testing.T) {
+	var opts ScaffoldOptions
+	opts.addFlags(pflag.NewFlagSet("scaffold", pflag.ContinueOnError))
+	opts.from = "ghcr.io/foo/example-app:v0.1.0"
+	opts.replicas = 4
+	opts.autoscaler = "hpa"
+	opts.cpuLimit = "100m"
+	opts.memoryLimit = "128Mi"
+	opts.variables = map[string]string{"greeting": "it's a 'test'", "empty": ""}
+	opts.imagePullSecrets = []string{"registry-credentials", "other"}
+	opts.hpaScaleUpStabilizationWindow = ptr[int32](0)
+	opts.disableMemoryAutoscaling = true
+
+	// the executor and the maximum replicas keep their default values, but stay in the command because they are explicit
+	command := equivalentCommand(opts, "executor", "max-replicas")
+	require.Contains(t, command, "--executor containerd-shim-spin")
+	require.Contains(t, command, "--max-replicas 3")
+
+	args := parseCommand(t, command)
+	require.Equal(t, []string{"spin", "kube", "scaffold"}, args[:3])
+
+	var parsed ScaffoldOptions
+	flags := pflag.NewFlagSet("scaffold", pflag.ContinueOnError)
+	parsed.addFlags(flags)
+	require.Nil(t, flags.Parse(args[3:]))
+	require.Equal(t, opts, parsed)
+}
@@ -0,0 +1,106 @@
+// Package keda contains the subset of the KEDA API types needed to generate KEDA resources, without depending on the
+// KEDA module itself.
+package keda
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the API group and version of the KEDA resources.
+var GroupVersion = schema.GroupVersion{Group: "keda.sh", Version: "v1alpha1"}
+
+// ScaledObject is a specification for a ScaledObject resource.
+type ScaledObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScaledObjectSpec `json:"spec"`
+}
+
+// ScaledObjectSpec is the spec for a ScaledObject resource.
+type ScaledObjectSpec struct {
+	ScaleTargetRef  *ScaleTarget    `json:"scaleTargetRef"`
+	MinReplicaCount *int32          `json:"minReplicaCount,omitempty"`
+	MaxReplicaCount *int32          `json:"maxReplicaCount,omitempty"`
+	Triggers        []ScaleTriggers `json:"triggers"`
+}
+
+// ScaleTarget holds the reference to the scale target object.
+type ScaleTarget struct {
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+}
+
+// ScaleTriggers reference the scaler that will be used.
+type ScaleTriggers struct {
+	Type              string             `json:"type"`
+	Name              string             `json:"name,omitempty"`
+	MetricType        string             `json:"metricType,omitempty"`
+	Metadata          map[string]string  `json:"metadata"`
+	AuthenticationRef *AuthenticationRef `json:"authenticationRef,omitempty"`
+}
+
+// AuthenticationRef points to the TriggerAuthentication object that is used to authenticate the scaler with the
+// environment.
+type AuthenticationRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// TriggerAuthentication defines how a trigger can authenticate.
+type TriggerAuthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TriggerAuthenticationSpec `json:"spec"`
+}
+
+// TriggerAuthenticationSpec defines the various ways to authenticate.
+type TriggerAuthenticationSpec struct {
+	SecretTargetRef []AuthSecretTargetRef `json:"secretTargetRef,omitempty"`
+}
+
+// AuthSecretTargetRef is used to authenticate using a reference to a secret.
+type AuthSecretTargetRef struct {
+	Parameter string `json:"parameter"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+}
+
+// HTTPGroupVersion is the API group and version of the KEDA HTTP add-on resources.
+var HTTPGroupVersion = schema.GroupVersion{Group: "http.keda.sh", Version: "v1alpha1"}
+
+// HTTPScaledObject is the specification for a KEDA HTTP add-on scaled object.
+type HTTPScaledObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HTTPScaledObjectSpec `json:"spec"`
+}
+
+// HTTPScaledObjectSpec defines the desired state of an HTTPScaledObject.
+type HTTPScaledObjectSpec struct {
+	Hosts                 []string        `json:"hosts,omitempty"`
+	PathPrefixes          []string        `json:"pathPrefixes,omitempty"`
+	ScaleTargetRef        HTTPScaleTarget `json:"scaleTargetRef"`
+	Replicas              *ReplicaStruct  `json:"replicas,omitempty"`
+	TargetPendingRequests *int32          `json:"targetPendingRequests,omitempty"`
+	ScaledownPeriod       *int32          `json:"scaledownPeriod,omitempty"`
+}
+
+// HTTPScaleTarget references the workload to scale and the service that routes traffic to it.
+type HTTPScaleTarget struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Service    string `json:"service"`
+	Port       int32  `json:"port"`
+}
+
+// ReplicaStruct contains the minimum and maximum amount of replicas to have in the deployment.
+type ReplicaStruct struct {
+	Min *int32 `json:"min,omitempty"`
+	Max *int32 `json:"max,omitempty"`
+}
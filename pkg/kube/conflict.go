@@ -0,0 +1,70 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conflictManager matches the field manager in the message of a field manager conflict, e.g.
+// conflict with "argocd-controller" using core.spinkube.dev/v1alpha1.
+var conflictManager = regexp.MustCompile(`^conflict with "([^"]*)"`)
+
+// FieldConflict is an applied field that another field manager owns.
+type FieldConflict struct {
+	Field   string
+	Manager string
+}
+
+// ConflictError is returned by ApplyObject when other field managers own some of the applied fields.
+type ConflictError struct {
+	Conflicts []FieldConflict
+	err       error
+}
+
+func (e *ConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d field(s) are owned by other field managers:", len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		fmt.Fprintf(&b, "\n  %s (owned by %s)", conflict.Field, conflict.Manager)
+	}
+
+	return b.String()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.err
+}
+
+// asConflictError turns the conflict status the API server returns for a server-side apply into a ConflictError, and
+// returns any other error as is.
+func asConflictError(err error) error {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return err
+	}
+
+	var conflicts []FieldConflict
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+
+		manager := cause.Message
+		if match := conflictManager.FindStringSubmatch(cause.Message); match != nil {
+			manager = match[1]
+		}
+
+		conflicts = append(conflicts, FieldConflict{Field: cause.Field, Manager: manager})
+	}
+
+	if len(conflicts) == 0 {
+		return err
+	}
+
+	return &ConflictError{Conflicts: conflicts, err: err}
+}
@@ -0,0 +1,182 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"testing"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestApplyObjectConflicts(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	conflict := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusConflict,
+		Reason:  metav1.StatusReasonConflict,
+		Message: `Apply failed with 2 conflicts: conflict with "argocd-controller" using core.spinkube.dev/v1alpha1: .spec.replicas`,
+		Details: &metav1.StatusDetails{
+			Group: "core.spinkube.dev",
+			Kind:  "spinapps",
+			Name:  "example-app",
+			Causes: []metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "argocd-controller" using core.spinkube.dev/v1alpha1`, Field: ".spec.replicas"},
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl-client-side-apply" using core.spinkube.dev/v1alpha1`, Field: ".spec.image"},
+			},
+		},
+	}}
+
+	var patches []client.PatchOptions
+	impl := New(fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, _ client.Object, _ client.Patch, opts ...client.PatchOption) error {
+			options := client.PatchOptions{}
+			options.ApplyOptions(opts)
+			patches = append(patches, options)
+
+			if *options.Force {
+				return nil
+			}
+
+			return conflict
+		},
+	}).Build(), nil)
+
+	app := &spinv1alpha1.SpinApp{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "core.spinkube.dev/v1alpha1", Kind: "SpinApp"},
+		ObjectMeta: metav1.ObjectMeta{Name: "example-app"},
+	}
+
+	_, err := impl.ApplyObject(context.Background(), app, ApplyOptions{Namespace: "default"})
+
+	var conflictErr *ConflictError
+	require.True(t, errors.As(err, &conflictErr))
+	require.Equal(t, []FieldConflict{
+		{Field: ".spec.replicas", Manager: "argocd-controller"},
+		{Field: ".spec.image", Manager: "kubectl-client-side-apply"},
+	}, conflictErr.Conflicts)
+	require.Equal(t, `2 field(s) are owned by other field managers:
+  .spec.replicas (owned by argocd-controller)
+  .spec.image (owned by kubectl-client-side-apply)`, err.Error())
+	require.True(t, apierrors.IsConflict(err))
+	require.Equal(t, FieldManager, patches[0].FieldManager)
+
+	_, err = impl.ApplyObject(context.Background(), app, ApplyOptions{Namespace: "default", FieldManager: "platform-team", ForceConflicts: true})
+	require.Nil(t, err)
+	require.Equal(t, "platform-team", patches[1].FieldManager)
+	require.True(t, *patches[1].Force)
+}
+
+func TestApplyObjectOtherErrors(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "core.spinkube.dev", Resource: "spinappexecutors"}, "containerd-shim-spin")
+	require.Equal(t, notFound, asConflictError(notFound))
+
+	// a conflict without field manager causes, e.g. an outdated resourceVersion, is returned as is
+	outdated := apierrors.NewConflict(schema.GroupResource{Group: "core.spinkube.dev", Resource: "spinapps"}, "example-app", errors.New("the object has been modified"))
+	require.Equal(t, outdated, asConflictError(outdated))
+}
+
+func TestApplyObjectResult(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"},
+		Data:       map[string]string{"greeting": "hello"},
+	}
+
+	// the fake client does not support server-side apply, so the interceptor applies the data of config maps
+	applyConfigMap := func(ctx context.Context, c client.WithWatch, obj client.Object, _ client.Patch, opts ...client.PatchOption) error {
+		options := client.PatchOptions{}
+		options.ApplyOptions(opts)
+		dryRun := len(options.DryRun) > 0
+		applied := obj.(*corev1.ConfigMap)
+
+		var current corev1.ConfigMap
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), &current); apierrors.IsNotFound(err) {
+			if dryRun {
+				return nil
+			}
+
+			return c.Create(ctx, applied)
+		} else if err != nil {
+			return err
+		}
+
+		if !maps.Equal(current.Data, applied.Data) {
+			current.Data = applied.Data
+			if !dryRun {
+				if err := c.Update(ctx, &current); err != nil {
+					return err
+				}
+			}
+		}
+
+		current.DeepCopyInto(applied)
+		return nil
+	}
+
+	testCases := []struct {
+		name     string
+		obj      *corev1.ConfigMap
+		dryRun   bool
+		expected ApplyResult
+	}{
+		{
+			name:     "created",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			expected: ApplyResultCreated,
+		},
+		{
+			name:     "configured",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings"}, Data: map[string]string{"greeting": "hi"}},
+			expected: ApplyResultConfigured,
+		},
+		{
+			name:     "unchanged",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings"}, Data: map[string]string{"greeting": "hello"}},
+			expected: ApplyResultUnchanged,
+		},
+		{
+			name:     "created with a dry run",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			dryRun:   true,
+			expected: ApplyResultCreated,
+		},
+		{
+			name:     "configured with a dry run",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings"}, Data: map[string]string{"greeting": "hi"}},
+			dryRun:   true,
+			expected: ApplyResultConfigured,
+		},
+		{
+			name:     "unchanged with a dry run",
+			obj:      &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings"}, Data: map[string]string{"greeting": "hello"}},
+			dryRun:   true,
+			expected: ApplyResultUnchanged,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			impl := New(fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).WithObjects(existing.DeepCopy()).WithInterceptorFuncs(interceptor.Funcs{
+				Patch: applyConfigMap,
+			}).Build(), nil)
+
+			tc.obj.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+			result, err := impl.ApplyObject(context.Background(), tc.obj, ApplyOptions{Namespace: "default", DryRun: tc.dryRun})
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
@@ -1,10 +1,19 @@
 package kube
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 
 	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -37,14 +46,106 @@ func (i *Impl) ListSpinApps(ctx context.Context, namespace string) (spinv1alpha1
 	return spinAppList, nil
 }
 
-func (i *Impl) ApplySpinApp(ctx context.Context, app *spinv1alpha1.SpinApp) error {
+// ApplyOptions are the options of ApplyObject.
+type ApplyOptions struct {
+	// Namespace is set on namespaced objects that do not have a namespace of their own.
+	Namespace string
+	// DryRun sends the request to the API server without persisting the result.
+	DryRun bool
+	// FieldManager owns the applied fields. It defaults to FieldManager.
+	FieldManager string
+	// ForceConflicts takes the ownership of fields that other field managers own, instead of failing with a
+	// ConflictError.
+	ForceConflicts bool
+}
+
+// ApplyResult tells how applying an object changed it.
+type ApplyResult string
+
+const (
+	// ApplyResultCreated is returned for objects that did not exist.
+	ApplyResultCreated ApplyResult = "created"
+	// ApplyResultConfigured is returned for existing objects that the apply changed.
+	ApplyResultConfigured ApplyResult = "configured"
+	// ApplyResultUnchanged is returned for existing objects that already matched the applied configuration.
+	ApplyResultUnchanged ApplyResult = "unchanged"
+)
+
+// ApplyObject applies an object of any kind with server-side apply and returns how the object changed. Whether the
+// object is namespaced is looked up in the REST mapping of its kind. When other field managers own some of the applied
+// fields and conflicts are not forced, the returned error is a ConflictError.
+func (i *Impl) ApplyObject(ctx context.Context, obj client.Object, opts ApplyOptions) (ApplyResult, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	mapping, err := i.kubeclient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return "", err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(opts.Namespace)
+		}
+	} else {
+		obj.SetNamespace("")
+	}
+
 	patchMethod := client.Apply
 	patchOptions := &client.PatchOptions{
-		Force:        ptr(true),
-		FieldManager: FieldManager,
+		Force:        ptr(opts.ForceConflicts),
+		FieldManager: cmp.Or(opts.FieldManager, FieldManager),
+	}
+
+	if opts.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gvk)
+	err = i.kubeclient.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
 	}
+	found := err == nil
 
-	return i.kubeclient.Patch(ctx, app, patchMethod, patchOptions)
+	if err := asConflictError(i.kubeclient.Patch(ctx, obj, patchMethod, patchOptions)); err != nil {
+		return "", err
+	}
+
+	switch {
+	case !found:
+		return ApplyResultCreated, nil
+	case opts.DryRun:
+		// a dry run does not change the resourceVersion, so the object the API server would persist is compared instead
+		changed, err := dryRunChanged(existing, obj)
+		if err != nil || changed {
+			return ApplyResultConfigured, err
+		}
+
+		return ApplyResultUnchanged, nil
+	case obj.GetResourceVersion() != existing.GetResourceVersion():
+		return ApplyResultConfigured, nil
+	default:
+		return ApplyResultUnchanged, nil
+	}
+}
+
+// dryRunChanged returns whether the object returned by a dry run differs from the existing object, ignoring the
+// metadata the API server maintains for every write.
+func dryRunChanged(existing *unstructured.Unstructured, obj client.Object) (bool, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+
+	// typed objects decoded by the client do not keep their kind
+	before, after := existing.DeepCopy(), &unstructured.Unstructured{Object: content}
+	after.SetGroupVersionKind(existing.GroupVersionKind())
+	for _, u := range []*unstructured.Unstructured{before, after} {
+		unstructured.RemoveNestedField(u.Object, "metadata", "managedFields")
+		unstructured.RemoveNestedField(u.Object, "metadata", "resourceVersion")
+	}
+
+	return !equality.Semantic.DeepEqual(before.Object, after.Object), nil
 }
 
 func (i *Impl) GetSpinApp(ctx context.Context, name client.ObjectKey) (spinv1alpha1.SpinApp, error) {
@@ -72,6 +173,63 @@ func (i *Impl) DeleteSpinApp(ctx context.Context, name client.ObjectKey) error {
 	return nil
 }
 
+// ListSpinAppExecutors returns all resources of type SpinAppExecutor in the given namespace. If namespace is the empty
+// string, it returns all SpinAppExecutor resources across all namespaces.
+func (i *Impl) ListSpinAppExecutors(ctx context.Context, namespace string) (spinv1alpha1.SpinAppExecutorList, error) {
+	var executorList spinv1alpha1.SpinAppExecutorList
+	err := i.kubeclient.List(ctx, &executorList, &client.ListOptions{
+		Namespace: namespace,
+	})
+	if err != nil {
+		return spinv1alpha1.SpinAppExecutorList{}, err
+	}
+
+	return executorList, nil
+}
+
+func (i *Impl) GetSpinAppExecutor(ctx context.Context, name client.ObjectKey) (spinv1alpha1.SpinAppExecutor, error) {
+	var executor spinv1alpha1.SpinAppExecutor
+	err := i.kubeclient.Get(ctx, name, &executor)
+	if err != nil {
+		return spinv1alpha1.SpinAppExecutor{}, err
+	}
+
+	return executor, nil
+}
+
+func (i *Impl) DeleteSpinAppExecutor(ctx context.Context, name client.ObjectKey) error {
+	executor, err := i.GetSpinAppExecutor(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return i.kubeclient.Delete(ctx, &executor)
+}
+
+// GetSecret returns the Secret with the given name.
+func (i *Impl) GetSecret(ctx context.Context, name client.ObjectKey) (corev1.Secret, error) {
+	var secret corev1.Secret
+	err := i.kubeclient.Get(ctx, name, &secret)
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+
+	return secret, nil
+}
+
+// GetUnstructured returns the object of the given kind with the given name. It is used for kinds that are not part of
+// the scheme of the client, such as the KEDA resources.
+func (i *Impl) GetUnstructured(ctx context.Context, gvk schema.GroupVersionKind, name client.ObjectKey) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	err := i.kubeclient.Get(ctx, name, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
 func ptr[T any](v T) *T {
 	return &v
 }
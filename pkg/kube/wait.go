@@ -0,0 +1,252 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pollInterval is how often WaitForSpinAppReady checks the rollout.
+var pollInterval = 2 * time.Second
+
+// RolloutStatus is the progress of the rollout of a SpinApp.
+type RolloutStatus struct {
+	Ready           bool
+	ReadyReplicas   int32
+	DesiredReplicas int32
+	// Message describes what the rollout is waiting for. It is empty once the rollout is complete.
+	Message string
+}
+
+// RolloutTimeoutError is returned by WaitForSpinAppReady when the rollout does not complete in time.
+type RolloutTimeoutError struct {
+	Name    string
+	Timeout time.Duration
+	Status  RolloutStatus
+	// Reasons explain why pods are not ready, such as image pull errors or crash loops.
+	Reasons []string
+}
+
+func (e *RolloutTimeoutError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "timed out after %s waiting for %s to become ready", e.Timeout, e.Name)
+	if e.Status.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Status.Message)
+	}
+
+	for _, reason := range e.Reasons {
+		fmt.Fprintf(&b, "\n  %s", reason)
+	}
+
+	return b.String()
+}
+
+// RolloutBaseline is the generation of a SpinApp and of its deployment before an apply. The generations are 0 for
+// objects that do not exist yet.
+type RolloutBaseline struct {
+	SpinAppGeneration    int64
+	DeploymentGeneration int64
+}
+
+// GetRolloutBaseline returns the generations of the SpinApp and its deployment, to be recorded before the SpinApp is
+// applied and passed to WaitForSpinAppReady.
+func (i *Impl) GetRolloutBaseline(ctx context.Context, name client.ObjectKey) (RolloutBaseline, error) {
+	var baseline RolloutBaseline
+
+	var app spinv1alpha1.SpinApp
+	if err := i.kubeclient.Get(ctx, name, &app); client.IgnoreNotFound(err) != nil {
+		return RolloutBaseline{}, err
+	}
+	baseline.SpinAppGeneration = app.Generation
+
+	var deployment appsv1.Deployment
+	if err := i.kubeclient.Get(ctx, name, &deployment); client.IgnoreNotFound(err) != nil {
+		return RolloutBaseline{}, err
+	}
+	baseline.DeploymentGeneration = deployment.Generation
+
+	return baseline, nil
+}
+
+// WaitForSpinAppReady waits until the deployment of the SpinApp is rolled out and the SpinApp reports all its replicas
+// as ready. When the apply changed the SpinApp since the baseline, the status of the deployment is only trusted once the
+// operator has updated it. progress, if set, is called with the status after every check. When the timeout expires,
+// the returned RolloutTimeoutError lists the reasons why the pods of the application are not ready.
+func (i *Impl) WaitForSpinAppReady(ctx context.Context, name client.ObjectKey, baseline RolloutBaseline, timeout time.Duration, progress func(RolloutStatus)) error {
+	var status RolloutStatus
+	err := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		status, err = i.rolloutStatus(ctx, name, baseline)
+		if err != nil {
+			return false, err
+		}
+
+		if progress != nil {
+			progress(status)
+		}
+
+		return status.Ready, nil
+	})
+
+	if !wait.Interrupted(err) {
+		return err
+	}
+
+	// the context of the wait has expired, so the reasons are looked up with a context of their own
+	reasonsCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	reasons, reasonsErr := i.rolloutFailureReasons(reasonsCtx, name)
+	if reasonsErr != nil {
+		reasons = append(reasons, fmt.Sprintf("could not look up the pods: %v", reasonsErr))
+	}
+
+	return &RolloutTimeoutError{
+		Name:    name.Name,
+		Timeout: timeout,
+		Status:  status,
+		Reasons: reasons,
+	}
+}
+
+// rolloutStatus checks the rollout of the deployment the operator creates for the SpinApp, with the same rules as
+// kubectl rollout status, and the ready replicas the SpinApp reports.
+func (i *Impl) rolloutStatus(ctx context.Context, name client.ObjectKey, baseline RolloutBaseline) (RolloutStatus, error) {
+	app, err := i.GetSpinApp(ctx, name)
+	if err != nil {
+		return RolloutStatus{}, err
+	}
+
+	status := RolloutStatus{ReadyReplicas: app.Status.ReadyReplicas, DesiredReplicas: app.Spec.Replicas}
+
+	var deployment appsv1.Deployment
+	err = i.kubeclient.Get(ctx, name, &deployment)
+	if apierrors.IsNotFound(err) {
+		status.Message = "waiting for the operator to create the deployment"
+		return status, nil
+	}
+
+	if err != nil {
+		return RolloutStatus{}, err
+	}
+
+	// the operator does not report the generation it has reconciled, so until it updates the deployment, the
+	// deployment is the rollout of the previous spec
+	if app.Generation != baseline.SpinAppGeneration && deployment.Generation <= baseline.DeploymentGeneration {
+		status.Message = "waiting for the operator to update the deployment"
+		return status, nil
+	}
+
+	status.DesiredReplicas = 1
+	if deployment.Spec.Replicas != nil {
+		status.DesiredReplicas = *deployment.Spec.Replicas
+	}
+
+	switch {
+	case deployment.Generation > deployment.Status.ObservedGeneration:
+		status.Message = "waiting for the deployment update to be observed"
+	case deployment.Status.UpdatedReplicas < status.DesiredReplicas:
+		status.Message = fmt.Sprintf("%d of %d new replicas have been updated", deployment.Status.UpdatedReplicas, status.DesiredReplicas)
+	case deployment.Status.Replicas > deployment.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d old replicas are pending termination", deployment.Status.Replicas-deployment.Status.UpdatedReplicas)
+	case deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d of %d updated replicas are available", deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas)
+	case app.Status.ReadyReplicas < status.DesiredReplicas:
+		status.Message = fmt.Sprintf("%d of %d replicas are ready", app.Status.ReadyReplicas, status.DesiredReplicas)
+	default:
+		status.Ready = true
+	}
+
+	return status, nil
+}
+
+// rolloutFailureReasons explains why the deployment of the SpinApp is not rolled out, from the conditions of the
+// deployment and the state of its pods.
+func (i *Impl) rolloutFailureReasons(ctx context.Context, name client.ObjectKey) ([]string, error) {
+	var deployment appsv1.Deployment
+	if err := i.kubeclient.Get(ctx, name, &deployment); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{fmt.Sprintf("deployment %s does not exist; check that the executor of the application exists and creates deployments", name.Name)}, nil
+		}
+
+		return nil, err
+	}
+
+	var reasons []string
+	for _, condition := range deployment.Status.Conditions {
+		failed := (condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue) ||
+			(condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse)
+		if failed {
+			reasons = append(reasons, fmt.Sprintf("deployment %s: %s: %s", deployment.Name, condition.Reason, condition.Message))
+		}
+	}
+
+	if deployment.Spec.Selector == nil {
+		return reasons, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods corev1.PodList
+	if err := i.kubeclient.List(ctx, &pods, client.InNamespace(name.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	return append(reasons, podFailureReasons(pods.Items)...), nil
+}
+
+// waitingReasonsInProgress are the reasons a container waits for that are part of a normal start.
+var waitingReasonsInProgress = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// podFailureReasons returns a line for every pod that cannot be scheduled and every container that cannot start or keeps
+// crashing, such as ImagePullBackOff, CrashLoopBackOff or a RuntimeClass that no node supports.
+func podFailureReasons(pods []corev1.Pod) []string {
+	var reasons []string
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				reasons = append(reasons, formatReason(pod.Name, condition.Reason, condition.Message))
+			}
+		}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			switch {
+			case status.State.Waiting != nil && !waitingReasonsInProgress[status.State.Waiting.Reason]:
+				message := status.State.Waiting.Message
+				if terminated := status.LastTerminationState.Terminated; terminated != nil {
+					message = strings.TrimSpace(fmt.Sprintf("%s (last exit code %d: %s)", message, terminated.ExitCode, terminated.Reason))
+				}
+
+				reasons = append(reasons, formatReason(pod.Name, status.State.Waiting.Reason, message))
+			case status.State.Terminated != nil && status.State.Terminated.ExitCode != 0:
+				reasons = append(reasons, formatReason(pod.Name, status.State.Terminated.Reason, fmt.Sprintf("exit code %d", status.State.Terminated.ExitCode)))
+			}
+		}
+	}
+
+	return reasons
+}
+
+func formatReason(pod, reason, message string) string {
+	if message == "" {
+		return fmt.Sprintf("pod %s: %s", pod, reason)
+	}
+
+	return fmt.Sprintf("pod %s: %s: %s", pod, reason, message)
+}
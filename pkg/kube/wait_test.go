@@ -0,0 +1,181 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	spinv1alpha1 "github.com/spinkube/spin-operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, spinv1alpha1.AddToScheme(scheme))
+
+	return scheme
+}
+
+func newTestImpl(t *testing.T, objects ...client.Object) *Impl {
+	return New(fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(objects...).WithStatusSubresource(&spinv1alpha1.SpinApp{}).Build(), nil)
+}
+
+func newTestDeployment(replicas int32, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"core.spinkube.dev/app-name": "example-app"}},
+		},
+		Status: status,
+	}
+}
+
+func TestGetRolloutBaseline(t *testing.T) {
+	key := client.ObjectKey{Namespace: "default", Name: "example-app"}
+
+	baseline, err := newTestImpl(t).GetRolloutBaseline(context.Background(), key)
+	require.Nil(t, err)
+	require.Equal(t, RolloutBaseline{}, baseline)
+
+	app := &spinv1alpha1.SpinApp{ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default", Generation: 2}}
+	deployment := newTestDeployment(2, appsv1.DeploymentStatus{})
+	deployment.Generation = 5
+
+	baseline, err = newTestImpl(t, app, deployment).GetRolloutBaseline(context.Background(), key)
+	require.Nil(t, err)
+	require.Equal(t, RolloutBaseline{SpinAppGeneration: 2, DeploymentGeneration: 5}, baseline)
+}
+
+func TestWaitForSpinAppReady(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	key := client.ObjectKey{Namespace: "default", Name: "example-app"}
+
+	t.Run("ready", func(t *testing.T) {
+		app := &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+			Spec:       spinv1alpha1.SpinAppSpec{Replicas: 2},
+			Status:     spinv1alpha1.SpinAppStatus{ReadyReplicas: 2},
+		}
+		deployment := newTestDeployment(2, appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2})
+
+		var statuses []RolloutStatus
+		err := newTestImpl(t, app, deployment).WaitForSpinAppReady(context.Background(), key, RolloutBaseline{}, time.Second, func(status RolloutStatus) {
+			statuses = append(statuses, status)
+		})
+		require.Nil(t, err)
+		require.Equal(t, []RolloutStatus{{Ready: true, ReadyReplicas: 2, DesiredReplicas: 2}}, statuses)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		app := &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+			Spec:       spinv1alpha1.SpinAppSpec{Replicas: 2},
+		}
+		deployment := newTestDeployment(2, appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2})
+		deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentReplicaFailure,
+			Status:  corev1.ConditionTrue,
+			Reason:  "FailedCreate",
+			Message: `pods "example-app-6d4f8" is forbidden: pod rejected: RuntimeClass "wasmtime-spin-v2" not found`,
+		}}
+
+		labels := map[string]string{"core.spinkube.dev/app-name": "example-app"}
+		pulling := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app-6d4f8-abcde", Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "example-app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: `Back-off pulling image "ghcr.io/foo/example-app:v0.1.0"`}},
+			}}},
+		}
+		crashing := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app-6d4f8-fghij", Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 "example-app",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+			}}},
+		}
+		unschedulable := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app-6d4f8-klmno", Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  "Unschedulable",
+				Message: "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.",
+			}}},
+		}
+		starting := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app-6d4f8-pqrst", Namespace: "default", Labels: labels},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "example-app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			}}},
+		}
+		other := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-app-abcde", Namespace: "default", Labels: map[string]string{"core.spinkube.dev/app-name": "other-app"}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "other-app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+			}}},
+		}
+
+		impl := newTestImpl(t, app, deployment, pulling, crashing, unschedulable, starting, other)
+		err := impl.WaitForSpinAppReady(context.Background(), key, RolloutBaseline{}, 50*time.Millisecond, nil)
+		require.Equal(t, `timed out after 50ms waiting for example-app to become ready: 0 of 2 updated replicas are available
+  deployment example-app: FailedCreate: pods "example-app-6d4f8" is forbidden: pod rejected: RuntimeClass "wasmtime-spin-v2" not found
+  pod example-app-6d4f8-abcde: ImagePullBackOff: Back-off pulling image "ghcr.io/foo/example-app:v0.1.0"
+  pod example-app-6d4f8-fghij: CrashLoopBackOff: (last exit code 1: Error)
+  pod example-app-6d4f8-klmno: Unschedulable: 0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.`, err.Error())
+	})
+
+	t.Run("update not reconciled yet", func(t *testing.T) {
+		// the previous spec is fully rolled out, but the operator has not updated the deployment for the new image
+		app := &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default", Generation: 3},
+			Spec:       spinv1alpha1.SpinAppSpec{Image: "ghcr.io/foo/example-app:v0.2.0", Replicas: 2},
+			Status:     spinv1alpha1.SpinAppStatus{ReadyReplicas: 2},
+		}
+		deployment := newTestDeployment(2, appsv1.DeploymentStatus{ObservedGeneration: 5, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2})
+		deployment.Generation = 5
+
+		impl := newTestImpl(t, app, deployment)
+		baseline := RolloutBaseline{SpinAppGeneration: 2, DeploymentGeneration: 5}
+
+		err := impl.WaitForSpinAppReady(context.Background(), key, baseline, 50*time.Millisecond, nil)
+		require.ErrorContains(t, err, "timed out after 50ms waiting for example-app to become ready: waiting for the operator to update the deployment")
+
+		// once the operator has updated the deployment, its rollout is the rollout of the applied spec
+		deployment.Generation = 6
+		deployment.Status.ObservedGeneration = 6
+		impl = newTestImpl(t, app, deployment)
+		require.Nil(t, impl.WaitForSpinAppReady(context.Background(), key, baseline, time.Second, nil))
+
+		// an apply that did not change the SpinApp does not wait for the operator
+		deployment.Generation = 5
+		deployment.Status.ObservedGeneration = 5
+		impl = newTestImpl(t, app, deployment)
+		require.Nil(t, impl.WaitForSpinAppReady(context.Background(), key, RolloutBaseline{SpinAppGeneration: 3, DeploymentGeneration: 5}, time.Second, nil))
+	})
+
+	t.Run("missing deployment", func(t *testing.T) {
+		app := &spinv1alpha1.SpinApp{
+			ObjectMeta: metav1.ObjectMeta{Name: "example-app", Namespace: "default"},
+			Spec:       spinv1alpha1.SpinAppSpec{Replicas: 2},
+		}
+
+		err := newTestImpl(t, app).WaitForSpinAppReady(context.Background(), key, RolloutBaseline{}, 50*time.Millisecond, nil)
+		require.EqualError(t, err, `timed out after 50ms waiting for example-app to become ready: waiting for the operator to create the deployment
+  deployment example-app does not exist; check that the executor of the application exists and creates deployments`)
+	})
+}
@@ -0,0 +1,128 @@
+// Package prompt asks the user for input on a terminal.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Prompter reads answers from in and writes questions to out.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New returns a Prompter that reads answers from in and writes questions to out.
+func New(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// readLine reads the next answer. A last answer that is not terminated by a newline is returned without an error.
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if errors.Is(err, io.EOF) && line != "" {
+		err = nil
+	}
+
+	return strings.TrimSpace(line), err
+}
+
+// Text asks for a free-form answer. An empty answer selects the default value. The question is asked again for as long
+// as validate, if set, rejects the answer.
+func (p *Prompter) Text(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if defaultValue != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+
+		if answer == "" {
+			answer = defaultValue
+		}
+
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				p.Error(err)
+				continue
+			}
+		}
+
+		return answer, nil
+	}
+}
+
+// Error prints the error, e.g. when answers that were valid on their own are rejected together.
+func (p *Prompter) Error(err error) {
+	fmt.Fprintf(p.out, "Error: %v\n", err)
+}
+
+// Select asks to choose one of the options, either by its number or by its value. An empty answer selects the default
+// value. The question is asked again for as long as validate, if set, rejects the choice.
+func (p *Prompter) Select(question string, options []string, defaultValue string, validate func(string) error) (string, error) {
+	fmt.Fprintf(p.out, "%s\n", question)
+	for i, option := range options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+
+	var choice string
+	_, err := p.Text(fmt.Sprintf("Choose 1-%d", len(options)), defaultValue, func(answer string) error {
+		choice = answer
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			choice = options[n-1]
+		}
+
+		if !slices.Contains(options, choice) {
+			return fmt.Errorf("'%s' is not one of the options", answer)
+		}
+
+		if validate != nil {
+			return validate(choice)
+		}
+
+		return nil
+	})
+
+	return choice, err
+}
+
+// Confirm asks a yes or no question. An empty answer selects the default value.
+func (p *Prompter) Confirm(question string, defaultValue bool) (bool, error) {
+	choices := "y/N"
+	if defaultValue {
+		choices = "Y/n"
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s (%s): ", question, choices)
+
+		answer, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "":
+			return defaultValue, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+
+		fmt.Fprintf(p.out, "Error: please answer yes or no\n")
+	}
+}
@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestText(t *testing.T) {
+	var out strings.Builder
+	p := New(strings.NewReader("\nbad\ngood\n"), &out)
+
+	answer, err := p.Text("Name", "", func(answer string) error {
+		if answer != "good" {
+			return fmt.Errorf("'%s' is not good", answer)
+		}
+
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, "good", answer)
+	require.Equal(t, "Name: Error: '' is not good\nName: Error: 'bad' is not good\nName: ", out.String())
+}
+
+func TestTextDefault(t *testing.T) {
+	var out strings.Builder
+	p := New(strings.NewReader("\n"), &out)
+
+	answer, err := p.Text("Replicas", "2", nil)
+	require.Nil(t, err)
+	require.Equal(t, "2", answer)
+	require.Equal(t, "Replicas [2]: ", out.String())
+}
+
+func TestTextEOF(t *testing.T) {
+	p := New(strings.NewReader("last"), io.Discard)
+
+	answer, err := p.Text("Name", "", nil)
+	require.Nil(t, err)
+	require.Equal(t, "last", answer)
+
+	_, err = p.Text("Name", "", nil)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestSelect(t *testing.T) {
+	testcases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "by number", input: "2\n", expected: "hpa"},
+		{name: "by value", input: "keda\n", expected: "keda"},
+		{name: "default", input: "\n", expected: "none"},
+		{name: "invalid then valid", input: "5\nfoo\n3\n", expected: "keda"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := New(strings.NewReader(tc.input), io.Discard)
+
+			choice, err := p.Select("Autoscaler", []string{"none", "hpa", "keda"}, "none", nil)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, choice)
+		})
+	}
+}
+
+func TestSelectValidate(t *testing.T) {
+	var out strings.Builder
+	p := New(strings.NewReader("2\n1\n"), &out)
+
+	choice, err := p.Select("Autoscaler", []string{"none", "hpa"}, "", func(choice string) error {
+		if choice == "hpa" {
+			return fmt.Errorf("cpu limits must be set when autoscaling is enabled")
+		}
+
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, "none", choice)
+	require.Equal(t, "Autoscaler\n  1) none\n  2) hpa\nChoose 1-2: Error: cpu limits must be set when autoscaling is enabled\nChoose 1-2: ", out.String())
+}
+
+func TestConfirm(t *testing.T) {
+	testcases := []struct {
+		input        string
+		defaultValue bool
+		expected     bool
+	}{
+		{input: "y\n", expected: true},
+		{input: "YES\n", expected: true},
+		{input: "n\n", defaultValue: true, expected: false},
+		{input: "\n", defaultValue: true, expected: true},
+		{input: "\n", expected: false},
+		{input: "maybe\ny\n", expected: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.input, func(t *testing.T) {
+			p := New(strings.NewReader(tc.input), io.Discard)
+
+			answer, err := p.Confirm("Are you sure?", tc.defaultValue)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, answer)
+		})
+	}
+}
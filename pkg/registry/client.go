@@ -0,0 +1,318 @@
+// Package registry implements the subset of the OCI distribution API needed to resolve image tags to digests.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	dockerparser "github.com/novln/docker-parser"
+)
+
+const (
+	dockerHubRegistry    = "docker.io"
+	dockerHubAPIRegistry = "registry-1.docker.io"
+)
+
+// manifestMediaTypes are the manifest formats accepted when resolving a tag. Indexes are preferred so that a
+// multi-platform image resolves to the digest of its index rather than to one of its platform manifests.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Client resolves image references against OCI registries.
+type Client struct {
+	httpClient *http.Client
+	keychain   Keychain
+}
+
+// NewClient returns a client that sends requests with httpClient and authenticates with the credentials found in the
+// keychain.
+func NewClient(httpClient *http.Client, keychain Keychain) *Client {
+	return &Client{
+		httpClient: httpClient,
+		keychain:   keychain,
+	}
+}
+
+// Reference is an image reference split into the parts the distribution API needs.
+type Reference struct {
+	// Registry is the host (and port) of the registry, e.g. ghcr.io.
+	Registry string
+	// Repository is the path of the repository in the registry, e.g. spinkube/hello.
+	Repository string
+	// Tag is the tag or the digest of the image.
+	Tag string
+}
+
+// ParseReference parses an image reference. References without a tag refer to the latest tag.
+func ParseReference(image string) (Reference, error) {
+	ref, err := dockerparser.Parse(image)
+	if err != nil {
+		return Reference{}, err
+	}
+
+	registry := ref.Registry()
+	repository := strings.TrimPrefix(ref.Repository(), registry+"/")
+	if registry == dockerHubRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return Reference{
+		Registry:   registry,
+		Repository: repository,
+		Tag:        ref.Tag(),
+	}, nil
+}
+
+// IsDigest reports whether the reference points to an immutable digest rather than a tag.
+func (r Reference) IsDigest() bool {
+	return strings.HasPrefix(r.Tag, "sha256:")
+}
+
+func (r Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIRegistry
+	}
+
+	return r.Registry
+}
+
+// ResolveDigest returns the digest the tag of the image currently points to.
+func (c *Client) ResolveDigest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.IsDigest() {
+		return ref.Tag, nil
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.apiHost(), ref.Repository, ref.Tag)
+
+	var authorization string
+	resp, err := c.do(ctx, http.MethodHead, manifestURL, ref, authorization)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	// retry with the credentials the registry asks for
+	if resp.StatusCode == http.StatusUnauthorized {
+		authorization, err = c.authorize(ctx, ref, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", fmt.Errorf("failed to authenticate with %s: %w", ref.Registry, err)
+		}
+
+		resp, err = c.do(ctx, http.MethodHead, manifestURL, ref, authorization)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve %s: the registry responded with %s", image, resp.Status)
+	}
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	return c.digestFromManifest(ctx, manifestURL, ref, authorization)
+}
+
+// PinDigest returns the image reference with its tag replaced by the digest, e.g. ghcr.io/foo/app@sha256:....
+func PinDigest(image, digest string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.IsDigest() {
+		return image, nil
+	}
+
+	// keep the registry and repository as written, dropping an explicit tag
+	repository := image
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository = image[:i]
+	}
+
+	return repository + "@" + digest, nil
+}
+
+// digestFromManifest computes the digest of the manifest for registries that do not return it in a header.
+func (c *Client) digestFromManifest(ctx context.Context, manifestURL string, ref Reference, authorization string) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, manifestURL, ref, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch the manifest of %s/%s:%s: the registry responded with %s", ref.Registry, ref.Repository, ref.Tag, resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *Client) do(ctx context.Context, method, url string, ref Reference, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry %s: %w", ref.Registry, err)
+	}
+
+	return resp, nil
+}
+
+// authorize returns the Authorization header that answers the challenge of the registry.
+func (c *Client) authorize(ctx context.Context, ref Reference, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	creds, found, err := c.credentials(ref.Registry)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !found {
+			return "", fmt.Errorf("the registry requires credentials, but none were found")
+		}
+
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(creds.Username, creds.Password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		token, err := c.fetchToken(ctx, ref, params, creds, found)
+		if err != nil {
+			return "", err
+		}
+
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge '%s'", challenge)
+	}
+}
+
+// fetchToken requests a bearer token from the token service named in the challenge.
+func (c *Client) fetchToken(ctx context.Context, ref Reference, params map[string]string, creds Credentials, withCredentials bool) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid token realm '%s'", params["realm"])
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if withCredentials {
+		req.SetBasicAuth(creds.Username, creds.Password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the token service responded with %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+
+	if token.Token != "" {
+		return token.Token, nil
+	}
+
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("the token service did not return a token")
+}
+
+func (c *Client) credentials(registry string) (Credentials, bool, error) {
+	if c.keychain == nil {
+		return Credentials{}, false, nil
+	}
+
+	return c.keychain.Resolve(registry)
+}
+
+// parseChallenge parses a WWW-Authenticate header of the form `<scheme> key="value",key="value"`. Quoted values may
+// contain commas.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+
+	for rest != "" {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.TrimSpace(strings.TrimLeft(key, ", "))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+
+	return scheme, params
+}
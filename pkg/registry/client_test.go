@@ -0,0 +1,236 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`
+
+// testRegistry is a stand-in registry that serves a single manifest for foo/app:v1.
+type testRegistry struct {
+	// auth is the authentication the registry requires: "", "basic" or "bearer".
+	auth string
+	// omitDigest makes the registry leave out the Docker-Content-Digest header.
+	omitDigest bool
+	username   string
+	password   string
+}
+
+func (r *testRegistry) start(t *testing.T) *httptest.Server {
+	server := httptest.NewUnstartedServer(nil)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			username, password, ok := req.BasicAuth()
+			if !ok || username != r.username || password != r.password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			require.Equal(t, "test-registry", req.URL.Query().Get("service"))
+			require.Equal(t, "repository:foo/app:pull", req.URL.Query().Get("scope"))
+			fmt.Fprint(w, `{"token":"secret-token"}`)
+			return
+		}
+
+		switch r.auth {
+		case "basic":
+			if username, password, ok := req.BasicAuth(); !ok || username != r.username || password != r.password {
+				w.Header().Set("WWW-Authenticate", `Basic realm="test-registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		case "bearer":
+			if req.Header.Get("Authorization") != "Bearer secret-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:foo/app:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if req.URL.Path != "/v2/foo/app/manifests/v1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		require.Contains(t, req.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		if !r.omitDigest {
+			w.Header().Set("Docker-Content-Digest", testDigest())
+		}
+
+		if req.Method == http.MethodGet {
+			fmt.Fprint(w, testManifest)
+		}
+	})
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func testDigest() string {
+	sum := sha256.Sum256([]byte(testManifest))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+type staticKeychain map[string]Credentials
+
+func (k staticKeychain) Resolve(registry string) (Credentials, bool, error) {
+	creds, found := k[registry]
+	return creds, found, nil
+}
+
+func TestResolveDigest(t *testing.T) {
+	testcases := []struct {
+		name          string
+		registry      testRegistry
+		image         string
+		keychain      Keychain
+		expectedError string
+	}{
+		{
+			name:  "anonymous",
+			image: "foo/app:v1",
+		},
+		{
+			name:     "digest computed from the manifest",
+			registry: testRegistry{omitDigest: true},
+			image:    "foo/app:v1",
+		},
+		{
+			name:     "bearer token",
+			registry: testRegistry{auth: "bearer", username: "user", password: "pass"},
+			image:    "foo/app:v1",
+			keychain: staticKeychain{"<host>": {Username: "user", Password: "pass"}},
+		},
+		{
+			name:     "basic auth",
+			registry: testRegistry{auth: "basic", username: "user", password: "pass"},
+			image:    "foo/app:v1",
+			keychain: staticKeychain{"<host>": {Username: "user", Password: "pass"}},
+		},
+		{
+			name:          "basic auth without credentials",
+			registry:      testRegistry{auth: "basic", username: "user", password: "pass"},
+			image:         "foo/app:v1",
+			expectedError: "failed to authenticate with <host>: the registry requires credentials, but none were found",
+		},
+		{
+			name:          "bearer token with wrong credentials",
+			registry:      testRegistry{auth: "bearer", username: "user", password: "pass"},
+			image:         "foo/app:v1",
+			keychain:      staticKeychain{"<host>": {Username: "user", Password: "wrong"}},
+			expectedError: "failed to authenticate with <host>: the token service responded with 401 Unauthorized",
+		},
+		{
+			name:          "unknown tag",
+			image:         "foo/app:v2",
+			expectedError: "failed to resolve <host>/foo/app:v2: the registry responded with 404 Not Found",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := tc.registry.start(t)
+			host := strings.TrimPrefix(server.URL, "https://")
+
+			if keychain, ok := tc.keychain.(staticKeychain); ok {
+				keychain[host] = keychain["<host>"]
+			}
+
+			client := NewClient(server.Client(), tc.keychain)
+			digest, err := client.ResolveDigest(context.Background(), host+"/"+tc.image)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, strings.ReplaceAll(tc.expectedError, "<host>", host))
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, testDigest(), digest)
+		})
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	testcases := []struct {
+		image    string
+		expected Reference
+	}{
+		{
+			image:    "ghcr.io/foo/app:v1",
+			expected: Reference{Registry: "ghcr.io", Repository: "foo/app", Tag: "v1"},
+		},
+		{
+			image:    "nginx",
+			expected: Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"},
+		},
+		{
+			image:    "localhost:5000/app:v2",
+			expected: Reference{Registry: "localhost:5000", Repository: "app", Tag: "v2"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.image, func(t *testing.T) {
+			ref, err := ParseReference(tc.image)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestPinDigest(t *testing.T) {
+	digest := testDigest()
+
+	testcases := []struct {
+		image    string
+		expected string
+	}{
+		{image: "ghcr.io/foo/app:v1", expected: "ghcr.io/foo/app@" + digest},
+		{image: "localhost:5000/app", expected: "localhost:5000/app@" + digest},
+		{image: "nginx:1.27", expected: "nginx@" + digest},
+		{image: "ghcr.io/foo/app@" + digest, expected: "ghcr.io/foo/app@" + digest},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.image, func(t *testing.T) {
+			pinned, err := PinDigest(tc.image, digest)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, pinned)
+		})
+	}
+}
+
+func TestDockerConfigResolve(t *testing.T) {
+	config, err := ParseDockerConfig([]byte(fmt.Sprintf(`{
+  "auths": {
+    "https://index.docker.io/v1/": {"auth": "%s"},
+    "ghcr.io": {"username": "octocat", "password": "token"}
+  }
+}`, base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass")))))
+	require.Nil(t, err)
+
+	creds, found, err := config.Resolve("docker.io")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, Credentials{Username: "hubuser", Password: "hubpass"}, creds)
+
+	creds, found, err = MultiKeychain{&DockerConfig{}, config}.Resolve("ghcr.io")
+	require.Nil(t, err)
+	require.True(t, found)
+	require.Equal(t, Credentials{Username: "octocat", Password: "token"}, creds)
+
+	_, found, err = config.Resolve("quay.io")
+	require.Nil(t, err)
+	require.False(t, found)
+}
@@ -0,0 +1,186 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials are the username and password used to authenticate with a registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Keychain looks up the credentials for a registry.
+type Keychain interface {
+	// Resolve returns the credentials for the registry host, and whether any were found.
+	Resolve(registry string) (Credentials, bool, error)
+}
+
+// MultiKeychain returns the credentials of the first keychain that has any for the registry.
+type MultiKeychain []Keychain
+
+// Resolve implements Keychain.
+func (m MultiKeychain) Resolve(registry string) (Credentials, bool, error) {
+	for _, keychain := range m {
+		creds, found, err := keychain.Resolve(registry)
+		if err != nil || found {
+			return creds, found, err
+		}
+	}
+
+	return Credentials{}, false, nil
+}
+
+// DockerConfig is the content of a docker config.json file, or of the .dockerconfigjson key of an image pull secret.
+type DockerConfig struct {
+	Auths       map[string]DockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore,omitempty"`
+	CredHelpers map[string]string     `json:"credHelpers,omitempty"`
+}
+
+// DockerAuth is the entry of a registry in a docker config.
+type DockerAuth struct {
+	Auth     string `json:"auth,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// ParseDockerConfig parses the content of a docker config.json file.
+func ParseDockerConfig(data []byte) (*DockerConfig, error) {
+	config := &DockerConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+
+	return config, nil
+}
+
+// LoadDockerConfig loads the docker config of the current user from $DOCKER_CONFIG/config.json, or from
+// ~/.docker/config.json. A missing file results in an empty config.
+func LoadDockerConfig() (*DockerConfig, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return &DockerConfig{}, nil
+		}
+
+		dir = filepath.Join(home, ".docker")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return &DockerConfig{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDockerConfig(data)
+}
+
+// Resolve implements Keychain. Credential helpers take precedence over the static entries in auths, as they do for the
+// docker CLI.
+func (c *DockerConfig) Resolve(registry string) (Credentials, bool, error) {
+	registry = normalizeRegistry(registry)
+
+	helper := c.CredsStore
+	for host, name := range c.CredHelpers {
+		if normalizeRegistry(host) == registry {
+			helper = name
+		}
+	}
+
+	if helper != "" {
+		creds, found, err := runCredentialHelper(helper, registry)
+		if err != nil || found {
+			return creds, found, err
+		}
+	}
+
+	for host, auth := range c.Auths {
+		if normalizeRegistry(host) != registry {
+			continue
+		}
+
+		if auth.Auth == "" {
+			return Credentials{Username: auth.Username, Password: auth.Password}, true, nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return Credentials{}, false, fmt.Errorf("invalid auth for registry %s: %w", host, err)
+		}
+
+		username, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return Credentials{}, false, fmt.Errorf("invalid auth for registry %s: expected <username>:<password>", host)
+		}
+
+		return Credentials{Username: username, Password: password}, true, nil
+	}
+
+	return Credentials{}, false, nil
+}
+
+// normalizeRegistry strips the scheme and path from the keys of a docker config, e.g. https://index.docker.io/v1/, and
+// maps the aliases of Docker Hub to docker.io.
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry, _, _ = strings.Cut(registry, "/")
+
+	switch registry {
+	case "index.docker.io", dockerHubAPIRegistry:
+		return dockerHubRegistry
+	}
+
+	return registry
+}
+
+// runCredentialHelper asks docker-credential-<helper> for the credentials of the registry.
+func runCredentialHelper(helper, registry string) (Credentials, bool, error) {
+	server := registry
+	if registry == dockerHubRegistry {
+		server = "https://index.docker.io/v1/"
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		// fall back to the static entries when the helper is not installed
+		if errors.Is(err, exec.ErrNotFound) {
+			return Credentials{}, false, nil
+		}
+
+		// helpers report missing credentials on stdout
+		if strings.Contains(stdout.String()+stderr.String(), "credentials not found") {
+			return Credentials{}, false, nil
+		}
+
+		return Credentials{}, false, fmt.Errorf("credential helper docker-credential-%s failed: %w", helper, err)
+	}
+
+	var output struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return Credentials{}, false, fmt.Errorf("invalid output from credential helper docker-credential-%s: %w", helper, err)
+	}
+
+	return Credentials{Username: output.Username, Password: output.Secret}, true, nil
+}